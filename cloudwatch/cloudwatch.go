@@ -0,0 +1,180 @@
+// Package cloudwatch streams the events of an AWS CloudWatch Logs log group
+// to the formatter.
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+)
+
+// lookback is how far back each poll looks for the events ingested late,
+// the events already shown are skipped.
+const lookback = 30 * time.Second
+
+// Options selects the events tailed.
+type Options struct {
+	// LogGroup is the name or the ARN of the log group.
+	LogGroup string
+	// StreamPrefix selects the log streams whose name starts with it.
+	StreamPrefix string
+	// Filter is a CloudWatch Logs filter pattern, eg. { $.level = "error" }.
+	Filter string
+	// Since is how far back the events written before are shown.
+	Since time.Duration
+	// Interval is the time between two polls.
+	Interval time.Duration
+	Region   string
+	Profile  string
+	// Endpoint overrides the endpoint of CloudWatch Logs, eg. for LocalStack.
+	Endpoint string
+}
+
+// Run polls the events of the log group until an interrupt signal is
+// received, following the pages of results and slowing down when
+// throttled. The lines are labeled with the names of their log streams.
+func Run(p *prettyjsonlog.PrettyJsonLog, opts Options) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var loadOpts []func(*config.LoadOptions) error
+	loadOpts = append(loadOpts, config.WithRetryMode(aws.RetryModeAdaptive))
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return err
+	}
+	client := cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
+	// The first request checks the credentials and the log group before
+	// streaming.
+	_, err = client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupIdentifier: aws.String(opts.LogGroup),
+		Limit:              aws.Int32(1),
+	})
+	if err != nil {
+		return err
+	}
+	t := &tailer{
+		client:  client,
+		opts:    opts,
+		sources: make(chan prettyjsonlog.Source),
+		streams: map[string]*io.PipeWriter{},
+		seen:    map[string]int64{},
+	}
+	go t.tail(ctx)
+	return p.RunSources(t.sources, cancel)
+}
+
+type tailer struct {
+	client  *cloudwatchlogs.Client
+	opts    Options
+	sources chan prettyjsonlog.Source
+	// streams are the writers of the sources of the log streams, by name.
+	streams map[string]*io.PipeWriter
+	// seen are the timestamps of the events shown within the lookback, by
+	// ID.
+	seen map[string]int64
+}
+
+// tail polls the events until ctx is canceled, then closes the sources.
+func (t *tailer) tail(ctx context.Context) {
+	defer func() {
+		for _, w := range t.streams {
+			w.Close()
+		}
+		close(t.sources)
+	}()
+	start := time.Now().Add(-t.opts.Since).UnixMilli()
+	delay := t.opts.Interval
+	for {
+		last, err := t.poll(ctx, start)
+		if ctx.Err() != nil {
+			return
+		}
+		delay = t.opts.Interval
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ThrottlingException" {
+				delay = min(2*delay, time.Minute)
+			}
+			log.Printf("cloudwatch: %v, retrying in %s", err, delay)
+		}
+		start = max(start, last-lookback.Milliseconds())
+		for id, ts := range t.seen {
+			if ts < start {
+				delete(t.seen, id)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// poll sends the events written from start that weren't shown yet, all the
+// pages of them, and returns the timestamp of the last one.
+func (t *tailer) poll(ctx context.Context, start int64) (int64, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupIdentifier: aws.String(t.opts.LogGroup),
+		StartTime:          aws.Int64(start),
+	}
+	if t.opts.StreamPrefix != "" {
+		input.LogStreamNamePrefix = aws.String(t.opts.StreamPrefix)
+	}
+	if t.opts.Filter != "" {
+		input.FilterPattern = aws.String(t.opts.Filter)
+	}
+	last := start
+	pages := cloudwatchlogs.NewFilterLogEventsPaginator(t.client, input)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return last, err
+		}
+		for _, ev := range page.Events {
+			id, ts := aws.ToString(ev.EventId), aws.ToInt64(ev.Timestamp)
+			if _, ok := t.seen[id]; ok {
+				continue
+			}
+			t.seen[id] = ts
+			last = max(last, ts)
+			w := t.stream(aws.ToString(ev.LogStreamName))
+			if _, err := io.WriteString(w, strings.TrimRight(aws.ToString(ev.Message), "\r\n")+"\n"); err != nil {
+				return last, err
+			}
+		}
+	}
+	return last, nil
+}
+
+// stream returns the writer of the source of a log stream, starting it the
+// first time.
+func (t *tailer) stream(name string) *io.PipeWriter {
+	w, ok := t.streams[name]
+	if !ok {
+		var r *io.PipeReader
+		r, w = io.Pipe()
+		t.streams[name] = w
+		t.sources <- prettyjsonlog.Source{Name: name, Reader: r}
+	}
+	return w
+}
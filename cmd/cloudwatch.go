@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/blesswinsamuel/pretty-json-log/cloudwatch"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var cloudwatchOptions cloudwatch.Options
+
+var cloudwatchCmd = &cobra.Command{
+	Use:   "cloudwatch [flags] log-group",
+	Short: "Stream and pretty print the events of an AWS CloudWatch Logs log group, labeled with their log streams",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		cloudwatchOptions.LogGroup = args[0]
+		return cloudwatch.Run(pl, cloudwatchOptions)
+	},
+}
+
+func init() {
+	cloudwatchCmd.Flags().StringVar(&cloudwatchOptions.StreamPrefix, "stream-prefix", "", "only show the log streams whose name starts with this prefix")
+	cloudwatchCmd.Flags().StringVar(&cloudwatchOptions.Filter, "filter", "", "CloudWatch Logs filter pattern (eg. '{ $.level = \"error\" }')")
+	cloudwatchCmd.Flags().DurationVar(&cloudwatchOptions.Since, "since", 10*time.Minute, "how far back the events written before are shown")
+	cloudwatchCmd.Flags().DurationVar(&cloudwatchOptions.Interval, "interval", 2*time.Second, "time between two polls of the events")
+	cloudwatchCmd.Flags().StringVar(&cloudwatchOptions.Region, "region", "", "AWS region (default $AWS_REGION or the region of the profile)")
+	cloudwatchCmd.Flags().StringVar(&cloudwatchOptions.Profile, "profile", "", "AWS profile (default $AWS_PROFILE)")
+	cloudwatchCmd.Flags().StringVar(&cloudwatchOptions.Endpoint, "endpoint-url", "", "endpoint of CloudWatch Logs (eg. 'http://localhost:4566' for LocalStack)")
+	rootCmd.AddCommand(cloudwatchCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/blesswinsamuel/pretty-json-log/docker"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var dockerOptions docker.Options
+
+var dockerCmd = &cobra.Command{
+	Use:   "docker [flags] container|compose-project",
+	Short: "Stream and pretty print the logs of a Docker container, or of the containers of a compose project",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		return docker.Run(pl, args[0], dockerOptions)
+	},
+}
+
+func init() {
+	dockerCmd.Flags().StringVarP(&dockerOptions.Host, "host", "H", "", "address of the Docker daemon (default $DOCKER_HOST or unix:///var/run/docker.sock)")
+	dockerCmd.Flags().IntVar(&dockerOptions.Tail, "tail", 10, "number of lines shown of the logs written before, -1 for all")
+	rootCmd.AddCommand(dockerCmd)
+}
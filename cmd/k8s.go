@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/blesswinsamuel/pretty-json-log/k8s"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var k8sOptions k8s.Options
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s [flags]",
+	Short: "Stream and pretty print the logs of the containers of Kubernetes pods, including the pods started later",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		return k8s.Run(pl, k8sOptions)
+	},
+}
+
+func init() {
+	k8sCmd.Flags().StringVar(&k8sOptions.Kubeconfig, "kubeconfig", "", "path of the kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	k8sCmd.Flags().StringVar(&k8sOptions.Context, "context", "", "kubeconfig context to use")
+	k8sCmd.Flags().StringVarP(&k8sOptions.Namespace, "namespace", "n", "", "namespace of the pods (default the namespace of the context)")
+	k8sCmd.Flags().BoolVarP(&k8sOptions.AllNamespaces, "all-namespaces", "A", false, "show the pods of all namespaces")
+	k8sCmd.Flags().StringVarP(&k8sOptions.Selector, "selector", "l", "", "label selector of the pods (eg. 'app=api')")
+	k8sCmd.Flags().StringVarP(&k8sOptions.Container, "container", "c", "", "regex of the names of the containers (default all)")
+	k8sCmd.Flags().Int64Var(&k8sOptions.Tail, "tail", 10, "number of lines shown of the logs written before, -1 for all")
+	rootCmd.AddCommand(k8sCmd)
+}
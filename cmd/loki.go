@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/blesswinsamuel/pretty-json-log/loki"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var lokiOptions loki.Options
+
+var lokiCmd = &cobra.Command{
+	Use:   "loki [flags]",
+	Short: "Stream and pretty print the logs matching a Loki query, labeled with their stream labels",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		return loki.Run(pl, lokiOptions)
+	},
+}
+
+func init() {
+	lokiCmd.Flags().StringVar(&lokiOptions.Addr, "addr", "http://localhost:3100", "URL of Loki, with the user and password for basic authentication if needed")
+	lokiCmd.Flags().StringVarP(&lokiOptions.Query, "query", "q", "", "LogQL log query (eg. '{app=\"api\"} |= \"error\"')")
+	lokiCmd.Flags().StringVar(&lokiOptions.OrgID, "org-id", "", "tenant of the logs (X-Scope-OrgID header)")
+	lokiCmd.Flags().DurationVar(&lokiOptions.Since, "since", time.Hour, "how far back the logs written before are shown")
+	lokiCmd.Flags().IntVar(&lokiOptions.Limit, "limit", 100, "maximum number of lines shown of the logs written before")
+	rootCmd.AddCommand(lokiCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/blesswinsamuel/pretty-json-log/sqlite"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query [flags] database [where]",
+	Short: "Pretty print the lines stored with --sink sqlite:database matching a SQL condition on the time, level, message, source and line columns (eg. \"level = 'ERROR' and json_extract(line, '$.status') >= 500\")",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The lines shown again are not stored again.
+		config := prettyJsonLogConfig
+		config.Sink = ""
+		pl, err := prettyjsonlog.NewPrettyJsonLog(config)
+		if err != nil {
+			return err
+		}
+		return sqlite.Query(pl, args[0], strings.Join(args[1:], " "))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+}
@@ -3,35 +3,180 @@ package cmd
 import (
 	"log"
 	"os"
+	"strings"
 
-	"github.com/blesswinsamuel/pretty-json-log/internal"
+	// register the kafka://, nats://, s3:// and gs:// inputs, the Lua scripts
+	// and the OTLP receiver
+	_ "github.com/blesswinsamuel/pretty-json-log/gcs"
+	_ "github.com/blesswinsamuel/pretty-json-log/kafka"
+	_ "github.com/blesswinsamuel/pretty-json-log/lua"
+	_ "github.com/blesswinsamuel/pretty-json-log/nats"
+	_ "github.com/blesswinsamuel/pretty-json-log/otlp"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	_ "github.com/blesswinsamuel/pretty-json-log/s3"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	prettyJsonLogConfig internal.PrettyJsonLogConfig
+	cfgFile             string
+	prettyJsonLogConfig prettyjsonlog.PrettyJsonLogConfig
 
 	rootCmd = &cobra.Command{
-		Use:   "pretty-json-log",
-		Short: "Pretty JSON Log parses JSON logs passed via stdin and shows it in easily readable format with colors",
-		Long:  ``,
-		Run: func(cmd *cobra.Command, args []string) {
-			pl := internal.NewPrettyJsonLog(prettyJsonLogConfig)
-			pl.Run()
+		Use:               "pretty-json-log [file...]",
+		Short:             "Pretty JSON Log parses JSON logs passed via stdin or files and shows it in easily readable format with colors",
+		Long:              ``,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.ArbitraryArgs,
+		PersistentPreRunE: initConfig,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+			if err != nil {
+				return err
+			}
+			return pl.Run(args)
 		},
 	}
 )
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ~/.config/pretty-json-log/config.yaml)")
 
-	rootCmd.Flags().StringVar(&prettyJsonLogConfig.TimeFieldKey, "time-field", "time,timestamp", "field that represents time")
-	rootCmd.Flags().StringVar(&prettyJsonLogConfig.LevelFieldKey, "level-field", "level,lvl", "field that represents log level")
-	rootCmd.Flags().StringVar(&prettyJsonLogConfig.MessageFieldKey, "message-field", "message,msg", "field that represents message")
-	rootCmd.Flags().StringVar(&prettyJsonLogConfig.OutputTimeFmt, "time-format", "{t}{ms}", "time format (eg. '{d} {t}{ms}')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Preset, "preset", "", "preset for a known logger ("+strings.Join(prettyjsonlog.PresetNames(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.TimeFieldKey, "time-field", "", "field that represents time, a key or the dot separated path of a nested field (default from the preset, eg. 'time,timestamp')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.LevelFieldKey, "level-field", "", "field that represents log level, a key or the dot separated path of a nested field (default from the preset, eg. 'level,lvl')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.MessageFieldKey, "message-field", "", "field that represents message, a key or the dot separated path of a nested field (default from the preset, eg. 'message,msg')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.OutputTimeFmt, "time-format", "{t}{ms}", "time format (eg. '{d} {t}{ms}'), or 'relative' to show the time since the first line (eg. '+00:03.412'), 'relative-start' since pretty-json-log started and 'delta' since the previous line")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.TimeLayouts, "time-layout", nil, "layout of the time field instead of guessing it, can be repeated: a Go layout ('2006-01-02 15:04:05'), a name like 'RFC3339' or a strptime pattern ('%d/%m/%Y %H:%M:%S')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.EpochUnit, "epoch-unit", "auto", "unit of numeric timestamps: auto (guessed from their magnitude), s, ms, us or ns")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Timezone, "timezone", "local", "timezone to show times in: local, original (keep the timezone of the log line) or a name like 'UTC' or 'America/New_York'")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.TimeDelta, "time-delta", false, "show the time since the previous line after the time (eg. 'Δ152ms')")
+	rootCmd.PersistentFlags().DurationVar(&prettyJsonLogConfig.DeltaThreshold, "delta-threshold", 0, "highlight the time since the previous line when above this duration (eg. '500ms')")
+	rootCmd.PersistentFlags().DurationVar(&prettyJsonLogConfig.WarnSkew, "warn-skew", 0, "color the times earlier than the previous line or in the future by more than this duration, eg. the clock skews when merging the logs of several hosts (eg. '5s')")
+	rootCmd.PersistentFlags().DurationVar(&prettyJsonLogConfig.Gap, "gap", 0, "show a separator like '――― 42.00s silence ―――' between lines further apart than this duration, by their time or the time they're read at (eg. '10s')")
+	rootCmd.PersistentFlags().StringVarP(&prettyJsonLogConfig.Output, "output", "o", "text", "output format: text, or json to write the lines left after filtering as compact JSON (lines that are not JSON are dropped)")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Format, "format", "", "Go template for the lines, given .time, .level, .msg, .fields, .rest (the other fields rendered), .raw and .source (eg. '{{.time}} [{{.level}}] {{.msg}} trace={{.fields.trace_id}}')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Redact, "redact", false, "replace the values of fields like password, token or authorization, and JWTs, AWS access keys and credit card numbers with [REDACTED]")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.RedactKeys, "redact-key", nil, "regex of the keys of fields to redact, can be repeated")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.RedactValues, "redact-value", nil, "regex of values to redact, can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.NoHTTPColors, "no-http-colors", false, "don't color the status codes (by class), methods, paths and client addresses of HTTP access logs")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.CallerSegments, "caller-segments", 2, "number of path segments kept of the caller, source and file fields (eg. 'server/handler.go:123')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.FullCaller, "full-caller", false, "show the full path of the caller, source and file fields")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Hyperlinks, "hyperlinks", false, "render the caller and trace ID fields as clickable links (OSC 8) when writing colors to a terminal")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.EditorURL, "editor-url", "", "URL template of the caller links, with {path}, {line} and {col} (default 'vscode://file{path}:{line}')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.TraceURL, "trace-url", "", "URL template of the trace ID links, with {id} (eg. 'http://localhost:16686/trace/{id}')")
+	rootCmd.PersistentFlags().StringSliceVar(&prettyJsonLogConfig.TraceFields, "trace-fields", nil, "keys or paths of the trace ID fields (default trace_id, traceId, trace.id and similar)")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.ComponentField, "component-field", "", "field shown as a colored tag before the message, the color derived from its value (eg. 'logger,component,service')")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.ComponentLength, "component-length", 0, "abbreviate the dotted component names, like Java loggers, to about this length as Logback does (eg. c.e.s.UserService), 0 to keep them")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.GroupBy, "group-by", "", "field (eg. 'trace_id') whose value groups the lines: the next lines of a group are indented under the first one and the value is given a stable color")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.Highlight, "highlight", nil, "color the parts of the message and values matching a regex, optionally followed by @ and a color (eg. 'timeout|refused@hi-white bg-red'), can be repeated")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.FieldOrder, "field-order", "sorted", "order of the fields: sorted by key, or original to keep the order of the log line")
+	rootCmd.PersistentFlags().StringSliceVar(&prettyJsonLogConfig.HiddenFields, "hide-fields", nil, "fields not shown, keys or prefixes of keys ending with '*' (eg. 'pid,hostname,_*')")
+	rootCmd.PersistentFlags().StringSliceVar(&prettyJsonLogConfig.PriorityFields, "priority-fields", nil, "fields shown first, in this order, before the others sorted by key (eg. 'requestId,method,path,status')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.ParseNestedJSON, "parse-nested-json", false, "show the string values holding JSON objects or arrays as nested values (eg. payload={id:1} instead of an escaped string)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Flatten, "flatten", false, "show nested values as separate fields with dot separated keys (eg. 'http.method=\"GET\" http.status=200')")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.MaxDepth, "max-depth", 0, "collapse objects and arrays nested deeper than this into '{…3 keys}' and '[…12 items]' (the top level fields are at depth 1, 0 means unlimited)")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.MaxValueLength, "max-value-length", 0, "truncate string values longer than this many characters (0 means unlimited)")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Bytes, "bytes", "raw", "how byte counts are shown: raw, human (eg. '1.4 MiB') or both (eg. '1.4 MiB (1468006)')")
+	rootCmd.PersistentFlags().StringSliceVar(&prettyJsonLogConfig.ByteFields, "byte-fields", nil, "keys or paths of the fields holding byte counts (default bytes, size, content_length and similar)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Wrap, "wrap", false, "wrap the fields of lines wider than the terminal onto indented lines")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Truncate, "truncate", false, "cut lines wider than the terminal")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.Width, "width", 0, "width used by --wrap and --truncate (default the width of the terminal)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Expand, "expand", false, "show large objects and arrays as indented JSON below the line")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.ExpandSize, "expand-size", 8, "expand objects and arrays with more than this many values with --expand")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.ExpandDepth, "expand-depth", 2, "expand objects and arrays nested deeper than this with --expand")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.NonJSON, "non-json", "plain", "how to show lines that are not JSON: plain, dim, marker or hide")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.SkipNonJSON, "skip-non-json", false, "hide lines that are not JSON (same as --non-json hide)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.ExtractJSON, "extract-json", false, "parse the JSON object at the end of mixed lines like '2024-01-01 app | {...}'")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.MaxLineSize, "max-line-size", 0, "truncate lines longer than this many bytes, with a warning (0 means unlimited)")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.Workers, "workers", 1, "parse and format this many lines concurrently on busy streams, keeping their order")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.BufferSize, "buffer-size", 10, "number of lines read ahead of the output")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.DropPolicy, "drop-policy", "block", "what to do when the buffer is full: block (slow down the input), drop-oldest or drop-new")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.Head, "head", 0, "only show the first N lines, and stop reading")
+	rootCmd.PersistentFlags().IntVar(&prettyJsonLogConfig.Tail, "tail", 0, "only show the last N lines, once the inputs are read (or on interrupt with --follow), not to be confused with the --tail of the docker and k8s subcommands, the number of lines written before shown")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Summary, "summary", false, "print the number of lines per level and their time range to stderr when done")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Footer, "footer", false, "keep a line below the logs written to a terminal with the lines read per second, per level and not parsed")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.TUI, "tui", false, "interactive viewer with scrollback, search (/), jump to next error (e) and follow toggle (F)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.NoPause, "no-pause", false, "don't pause and resume the output when space is pressed in the terminal")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Out, "out", "", "also write the rendered lines to this file")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.OutColor, "out-color", "never", "whether to use colors in the --out file: always or never")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Sink, "sink", "", "also store the parsed lines, to show them again with the query subcommand (eg. 'sqlite:session.db')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Color, "color", "auto", "when to use colors: auto (if stdout is a terminal and NO_COLOR is not set), always or never")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Theme, "theme", "", "color theme ("+strings.Join(prettyjsonlog.ThemeNames(), ", ")+") (default \"dark\")")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.MinLevel, "min-level", "", "hide lines below this log level (eg. 'info')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Since, "since", "", "hide lines before this time (eg. '2024-01-02T15:04:05Z') or duration before now (eg. '15m', '2d')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Until, "until", "", "hide lines after this time or duration before now")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.FailOn, "fail-on", "", "exit with an error if a line at or above this log level was read (eg. 'error')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Grep, "grep", "", "only show lines whose message matches this regex")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.GrepV, "grep-v", "", "hide lines whose message matches this regex")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.GrepRaw, "grep-raw", false, "match --grep and --grep-v against the whole raw line instead of the message")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Filter, "filter", "", "only show lines matching this jq expression (eg. '.status >= 500 and .service == \"api\"')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Alert, "alert", "", "ring the terminal bell when a line matching this condition is shown, with the syntax of the highlight rules and the level compared by severity (eg. 'level >= error || status >= 500')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.AlertNotify, "alert-notify", false, "also fire a desktop notification on --alert (notify-send on Linux, osascript on macOS)")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.ExecOnMatch, "exec-on-match", "", "run the --exec command for each line shown matching this jq expression (eg. '.level == \"fatal\"')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Exec, "exec", "", "shell command run by --exec-on-match, one at a time, with the line as JSON on stdin and {json}, {level}, {msg} and {time} replaced by its quoted values (eg. 'notify-send {level} {msg}')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.TransformCmd, "transform-cmd", "", "shell command the lines are piped through as JSON before being shown, writing a line replacing each one (null to drop it), one per --workers (eg. 'jq -c --unbuffered \".user |= .name\"')")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.Parsers, "parser", nil, "parse the lines that are neither JSON nor logfmt with a plugin, can be repeated: a built-in parser (access-log, logback, spring-boot), a pattern of the config, grok:pattern for a grok pattern or a regex whose named groups are the fields (eg. 'grok:%{IP:client} %{WORD:method}'), or exec:command, reading {\"line\": \"...\"} for each line and writing back its fields as a JSON object or null")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Renderer, "renderer", "", "render the lines with a plugin instead of the default layout: a registered renderer or exec:command, reading {\"time\", \"level\", \"msg\", \"source\", \"fields\"} for each line and writing back {\"text\": \"...\"}")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Script, "script", "", "Lua script (.lua) defining on_line(record), called with the fields of each line to change them in place or return new ones, or return false to drop the line (eg. 'record.duration = record.end_ts - record.start_ts')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Webhook, "webhook", "", "URL the lines shown matching --webhook-on-match are posted to, as a message for Slack and Discord webhooks and as is for the others")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.WebhookOnMatch, "webhook-on-match", "", "post the lines shown matching this jq expression to the --webhook URL (eg. '.level == \"fatal\"')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.WebhookRate, "webhook-rate", "10/m", "maximum rate of the lines posted to the webhook, the others are skipped and counted in the next one")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.WebhookTemplate, "webhook-template", "", "template of the payload posted to the webhook, with the functions of --format and .level, .msg, .time, .source, .fields, .json (the line), .text (a message) and .skipped (eg. '{\"msg\": {{json .msg}}}')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.Sample, "sample", "", "only show n out of every m lines below WARN (eg. '1/100')")
+	rootCmd.PersistentFlags().StringVar(&prettyJsonLogConfig.RateLimit, "rate-limit", "", "limit the rate of lines below WARN, dropping the others (eg. '200/s', '1000/m')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Dedupe, "dedupe", false, "collapse consecutive identical lines (except for the time) into one with a repeat count")
+	rootCmd.PersistentFlags().StringSliceVar(&prettyJsonLogConfig.DedupeKeys, "dedupe-keys", nil, "fields compared to find identical lines with --dedupe (default all fields but the time)")
+	rootCmd.PersistentFlags().DurationVar(&prettyJsonLogConfig.DedupeWindow, "dedupe-window", 0, "only collapse lines within this duration of the first one with --dedupe (eg. '10s')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.Prefix, "prefix", false, "prefix each line with the name of the file it was read from (the default when reading multiple files)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.NoPrefix, "no-prefix", false, "don't prefix the lines with the file name when reading multiple files")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.StripPrefix, "strip-prefix", false, "strip the prefixes added by docker-compose ('service_1  | '), kubectl logs --prefix ('[pod/name/container] ') and docker logs --timestamps before parsing")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.PrefixLabel, "prefix-label", false, "show the service or pod name stripped with --strip-prefix as a label")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.Listen, "listen", nil, "receive the logs on an address instead of reading files, can be repeated: udp://host:port or tcp://host:port, raw JSON lines or syslog messages, http://host:port, NDJSON POSTed to any path, Loki push requests or OTLP logs on /v1/logs, or otlp://host:port, an OTLP gRPC logs receiver (eg. 'udp://0.0.0.0:5514')")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.Inputs, "input", nil, "read an input after the files given as arguments, can be repeated: a file, an http:// or https:// URL, an object or the objects starting with a prefix in a bucket, read in order with the default credentials (s3://bucket/key or gs://bucket/name), or a stream URL read until interrupted and reconnected with backoff, ws:// or wss:// for WebSocket messages, sse+http:// or sse+https:// for Server-Sent Events, kafka://broker/topic?group=name&from=beginning&meta=true for the messages of a Kafka topic, nats://server/subject or nats://server/subject?stream=name&from=beginning for NATS messages or a JetStream stream (eg. 'ws://localhost:8080/logs')")
+	rootCmd.PersistentFlags().StringArrayVar(&prettyJsonLogConfig.Headers, "header", nil, "header sent with the requests of the http:// and https:// inputs, can be repeated (eg. 'Authorization: Bearer token')")
+	rootCmd.PersistentFlags().BoolVarP(&prettyJsonLogConfig.Follow, "follow", "f", false, "keep reading files as they grow, reopening them when they are truncated or rotated (like 'tail -F')")
+	rootCmd.PersistentFlags().BoolVar(&prettyJsonLogConfig.NoExitOnEOF, "no-exit-on-eof", false, "keep reading stdin and named pipes once their writer closed them, until interrupted")
 }
 
-func initConfig() {
+// initConfig loads the config file and then re-applies the flags that were
+// set on the command line, so that they take precedence over the file.
+func initConfig(cmd *cobra.Command, args []string) error {
+	type flagValue struct {
+		flag  *pflag.Flag
+		value string
+		slice []string
+	}
+	var changed []flagValue
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		v := flagValue{flag: f, value: f.Value.String()}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			v.slice = append([]string(nil), sv.GetSlice()...)
+		}
+		changed = append(changed, v)
+	})
+
+	path := cfgFile
+	if path == "" {
+		path = prettyjsonlog.DefaultConfigPath()
+	}
+	if err := prettyjsonlog.LoadConfigFile(path, &prettyJsonLogConfig, cfgFile != ""); err != nil {
+		return err
+	}
+
+	for _, v := range changed {
+		if sv, ok := v.flag.Value.(pflag.SliceValue); ok {
+			if err := sv.Replace(v.slice); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := v.flag.Value.Set(v.value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func Execute() {
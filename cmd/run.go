@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- command [args...]",
+	Short: "Run a command and pretty print its stdout and stderr, exiting with its exit code",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		code, err := pl.RunCommand(args)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().BoolVar(&prettyJsonLogConfig.PTY, "pty", false, "run the command in a PTY so that it keeps its colors and progress output (stdout and stderr are merged)")
+	rootCmd.AddCommand(runCmd)
+}
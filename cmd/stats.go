@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/spf13/cobra"
+)
+
+var statsOptions prettyjsonlog.StatsOptions
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [flags] [file...]",
+	Short: "Show the number of lines, the error rate and the percentiles of a duration field per group of lines instead of the lines (eg. 'stats --by status,path --duration duration_ms')",
+	Args:  cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pl, err := prettyjsonlog.NewPrettyJsonLog(prettyJsonLogConfig)
+		if err != nil {
+			return err
+		}
+		return pl.RunStats(args, statsOptions)
+	},
+}
+
+func init() {
+	statsCmd.Flags().StringSliceVar(&statsOptions.By, "by", nil, "keys or paths of the fields grouping the lines (eg. 'status,path')")
+	statsCmd.Flags().StringVar(&statsOptions.Duration, "duration", "", "key or path of a numeric or duration field (eg. 'duration_ms' or 'latency' with values like '12.5ms') whose p50 and p95 are shown")
+	statsCmd.Flags().IntVar(&statsOptions.Top, "top", 20, "number of groups shown, the most frequent first (0 for all)")
+	rootCmd.AddCommand(statsCmd)
+}
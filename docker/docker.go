@@ -0,0 +1,334 @@
+// Package docker streams the logs of Docker containers to the formatter,
+// using the API of the Docker daemon.
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+)
+
+// composeProjectLabel is the label of the containers of a compose project.
+const composeProjectLabel = "com.docker.compose.project"
+
+// Options selects the containers whose logs are streamed.
+type Options struct {
+	// Host is the address of the Docker daemon, $DOCKER_HOST or the local
+	// socket by default.
+	Host string
+	// Tail is the number of lines shown of the logs written before, -1 for
+	// all of them.
+	Tail int
+}
+
+// Run streams the logs of the given containers, by name or ID, or of the
+// containers of a compose project, including the ones started later, until
+// an interrupt signal is received. The lines are labeled with the container
+// names when there are several.
+func Run(p *prettyjsonlog.PrettyJsonLog, target string, opts Options) error {
+	c, err := newClient(opts.Host)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filter := map[string][]string{"label": {composeProjectLabel + "=" + target}}
+	var containers []container
+	if err := c.get(ctx, "/containers/json?filters="+url.QueryEscape(mustJSON(filter)), &containers); err != nil {
+		return err
+	}
+	project := len(containers) > 0
+	if !project {
+		var ct container
+		if err := c.get(ctx, "/containers/"+url.PathEscape(target)+"/json", &ct); err != nil {
+			return err
+		}
+		containers = []container{ct}
+	}
+
+	t := &tailer{c: c, opts: opts, sources: make(chan prettyjsonlog.Source), active: map[string]bool{}, labels: project}
+	go func() {
+		defer func() {
+			t.wg.Wait()
+			close(t.sources)
+		}()
+		for _, ct := range containers {
+			t.start(ctx, ct.ID)
+		}
+		if project {
+			t.watch(ctx, filter)
+		}
+	}()
+	return p.RunSources(t.sources, cancel)
+}
+
+type container struct {
+	ID     string   `json:"Id"`
+	Name   string   `json:"Name"`
+	Names  []string `json:"Names"`
+	Config struct {
+		Tty bool `json:"Tty"`
+	} `json:"Config"`
+	State struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+}
+
+type tailer struct {
+	c       *client
+	opts    Options
+	sources chan prettyjsonlog.Source
+	labels  bool
+
+	mu     sync.Mutex
+	active map[string]bool
+	wg     sync.WaitGroup
+}
+
+// watch starts the log streams of the containers started later, until ctx
+// is canceled.
+func (t *tailer) watch(ctx context.Context, filter map[string][]string) {
+	filter["type"] = []string{"container"}
+	filter["event"] = []string{"start"}
+	resp, err := t.c.do(ctx, "/events?filters="+url.QueryEscape(mustJSON(filter)))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("watching containers: %v", err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+	d := json.NewDecoder(resp.Body)
+	for {
+		var ev struct {
+			ID string `json:"id"`
+		}
+		if err := d.Decode(&ev); err != nil {
+			if ctx.Err() == nil && err != io.EOF {
+				log.Printf("watching containers: %v", err)
+			}
+			return
+		}
+		t.start(ctx, ev.ID)
+	}
+}
+
+// start starts streaming the logs of a running container if it isn't
+// streamed yet.
+func (t *tailer) start(ctx context.Context, id string) {
+	var ct container
+	if err := t.c.get(ctx, "/containers/"+id+"/json", &ct); err != nil {
+		log.Printf("%s: %v", id, err)
+		return
+	}
+	t.mu.Lock()
+	if t.active[ct.ID] {
+		t.mu.Unlock()
+		return
+	}
+	t.active[ct.ID] = true
+	t.mu.Unlock()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.stream(ctx, ct)
+		t.mu.Lock()
+		delete(t.active, ct.ID)
+		t.mu.Unlock()
+	}()
+}
+
+// stream sends the logs of a container to the sources, stdout and stderr
+// separately, and waits until they end.
+func (t *tailer) stream(ctx context.Context, ct container) {
+	tail := "all"
+	if t.opts.Tail >= 0 {
+		tail = strconv.Itoa(t.opts.Tail)
+	}
+	follow := "0"
+	if ct.State.Running {
+		follow = "1"
+	}
+	name := strings.TrimPrefix(ct.Name, "/")
+	resp, err := t.c.do(ctx, "/containers/"+ct.ID+"/logs?stdout=1&stderr=1&follow="+follow+"&tail="+tail)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("%s: %v", name, err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+	if !t.labels {
+		name = ""
+	}
+	body := &ctxReader{ctx: ctx, r: resp.Body}
+	if ct.Config.Tty {
+		// The output of the containers with a TTY is not multiplexed.
+		t.send(ctx, prettyjsonlog.Source{Name: name, Reader: body})
+		return
+	}
+	stdout, stdoutW := io.Pipe()
+	stderr, stderrW := io.Pipe()
+	go func() {
+		err := demux(body, stdoutW, stderrW)
+		stdoutW.CloseWithError(err)
+		stderrW.CloseWithError(err)
+	}()
+	var wg sync.WaitGroup
+	for _, src := range []prettyjsonlog.Source{{Name: name, Reader: stdout}, {Name: name, Stream: "stderr", Reader: stderr}} {
+		wg.Add(1)
+		go func(src prettyjsonlog.Source) {
+			defer wg.Done()
+			t.send(ctx, src)
+		}(src)
+	}
+	wg.Wait()
+}
+
+// send sends a source and waits until it's read to the end.
+func (t *tailer) send(ctx context.Context, src prettyjsonlog.Source) {
+	done := &doneReader{r: src.Reader, done: make(chan struct{})}
+	src.Reader = done
+	select {
+	case t.sources <- src:
+		<-done.done
+	case <-ctx.Done():
+	}
+}
+
+// demux splits the multiplexed output of a container without a TTY: frames
+// made of an 8 bytes header, holding the stream (1 for stdout, 2 for stderr)
+// and the big endian size of the payload, followed by the payload.
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// ctxReader reports the end of the stream instead of an error once ctx is
+// canceled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	if err != nil && c.ctx.Err() != nil {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// doneReader closes done when the stream ends.
+type doneReader struct {
+	r    io.Reader
+	done chan struct{}
+	once sync.Once
+}
+
+func (d *doneReader) Read(b []byte) (int, error) {
+	n, err := d.r.Read(b)
+	if err != nil {
+		d.once.Do(func() { close(d.done) })
+	}
+	return n, err
+}
+
+// client calls the API of the Docker daemon.
+type client struct {
+	http *http.Client
+	base string
+}
+
+func newClient(host string) (*client, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host: %w", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		return &client{
+			http: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", u.Path)
+				},
+			}},
+			base: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &client{http: &http.Client{}, base: "http://" + u.Host}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host %q", host)
+}
+
+// do sends a GET request to the API and returns the response if successful.
+func (c *client) do(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("docker: %s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("docker: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// get decodes the JSON response of a GET request to the API.
+func (c *client) get(ctx context.Context, path string, v interface{}) error {
+	resp, err := c.do(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func mustJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,122 @@
+// Package gcs reads the objects of Google Cloud Storage buckets as inputs of
+// the formatter, with gs://bucket/name URLs and the application default
+// credentials. Importing it registers the gs scheme.
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	defaultEndpoint = "https://storage.googleapis.com/storage/v1"
+	scope           = "https://www.googleapis.com/auth/devstorage.read_only"
+)
+
+func init() {
+	prettyjsonlog.RegisterObjectStore("gs", list)
+}
+
+// list returns the object with the name of the URL, or the objects whose
+// name starts with it in lexicographic order, skipping the folder markers,
+// with the JSON API. Like the client libraries, $STORAGE_EMULATOR_HOST
+// selects an emulator, requested without credentials.
+func list(ctx context.Context, u *url.URL) ([]prettyjsonlog.Object, error) {
+	endpoint := defaultEndpoint
+	client := http.DefaultClient
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		endpoint = strings.TrimSuffix(host, "/") + "/storage/v1"
+	} else {
+		var err error
+		if client, err = google.DefaultClient(ctx, scope); err != nil {
+			return nil, err
+		}
+	}
+	bucket, prefix := u.Host, strings.TrimPrefix(u.Path, "/")
+	object := func(name string) prettyjsonlog.Object {
+		return prettyjsonlog.Object{
+			Name: "gs://" + bucket + "/" + name,
+			Open: func(ctx context.Context) (io.ReadCloser, error) {
+				return get(ctx, client, endpoint+"/b/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(name)+"?alt=media")
+			},
+		}
+	}
+	var objects []prettyjsonlog.Object
+	pageToken := ""
+	for {
+		q := url.Values{"prefix": {prefix}, "fields": {"items/name,nextPageToken"}}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		body, err := get(ctx, client, endpoint+"/b/"+url.PathEscape(bucket)+"/o?"+q.Encode())
+		if err != nil {
+			if prefix != "" && objects == nil {
+				// Without the permission to list the bucket the object can
+				// still be read.
+				return []prettyjsonlog.Object{object(prefix)}, nil
+			}
+			return nil, err
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if item.Name == prefix && !strings.HasSuffix(prefix, "/") {
+				return []prettyjsonlog.Object{object(item.Name)}, nil
+			}
+			if !strings.HasSuffix(item.Name, "/") {
+				objects = append(objects, object(item.Name))
+			}
+		}
+		if page.NextPageToken == "" {
+			return objects, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// get returns the body of the response to a GET request, or the error
+// message of the API.
+func get(ctx context.Context, client *http.Client, target string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
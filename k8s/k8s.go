@@ -0,0 +1,224 @@
+// Package k8s streams the logs of the containers of Kubernetes pods to the
+// formatter.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Options selects the containers whose logs are streamed.
+type Options struct {
+	Kubeconfig    string
+	Context       string
+	Namespace     string
+	AllNamespaces bool
+	// Selector is a label selector of the pods, eg. app=api.
+	Selector string
+	// Container is a regex of the names of the containers, all of them if
+	// empty.
+	Container string
+	// Tail is the number of lines shown of the logs written before, -1 for
+	// all of them.
+	Tail int64
+}
+
+// Run streams the logs of the containers of the pods matching opts, including
+// the pods started later, until an interrupt signal is received. The lines
+// are labeled with the pod and container names.
+func Run(p *prettyjsonlog.PrettyJsonLog, opts Options) error {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: opts.Context})
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return err
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		if namespace, _, err = config.Namespace(); err != nil {
+			return err
+		}
+	}
+	if opts.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+	var container *regexp.Regexp
+	if opts.Container != "" {
+		if container, err = regexp.Compile(opts.Container); err != nil {
+			return fmt.Errorf("invalid container pattern: %w", err)
+		}
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t := &tailer{
+		client:    client,
+		opts:      opts,
+		namespace: namespace,
+		container: container,
+		sources:   make(chan prettyjsonlog.Source),
+		active:    map[string]bool{},
+	}
+	// The first list checks the access to the cluster before streaming.
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: opts.Selector})
+	if err != nil {
+		return err
+	}
+	go t.watch(ctx, pods)
+	return p.RunSources(t.sources, cancel)
+}
+
+type tailer struct {
+	client    kubernetes.Interface
+	opts      Options
+	namespace string
+	container *regexp.Regexp
+	sources   chan prettyjsonlog.Source
+
+	mu     sync.Mutex
+	active map[string]bool
+	wg     sync.WaitGroup
+}
+
+// watch starts the log streams of the listed pods and of the pods added or
+// changed later, until ctx is canceled. Then it closes the sources once the
+// streams have ended.
+func (t *tailer) watch(ctx context.Context, pods *corev1.PodList) {
+	defer func() {
+		t.wg.Wait()
+		close(t.sources)
+	}()
+	for i := range pods.Items {
+		t.startPod(ctx, &pods.Items[i])
+	}
+	resourceVersion := pods.ResourceVersion
+	for ctx.Err() == nil {
+		w, err := t.client.CoreV1().Pods(t.namespace).Watch(ctx, metav1.ListOptions{LabelSelector: t.opts.Selector, ResourceVersion: resourceVersion})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("watching pods: %v", err)
+			}
+			resourceVersion = ""
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+		t.handleEvents(ctx, w, &resourceVersion)
+		w.Stop()
+	}
+}
+
+// handleEvents starts the log streams of the pods added or changed until the
+// watch ends or ctx is canceled.
+func (t *tailer) handleEvents(ctx context.Context, w watch.Interface, resourceVersion *string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			if ev.Type != watch.Added && ev.Type != watch.Modified {
+				continue
+			}
+			if pod, ok := ev.Object.(*corev1.Pod); ok {
+				*resourceVersion = pod.ResourceVersion
+				t.startPod(ctx, pod)
+			}
+		}
+	}
+}
+
+// startPod starts streaming the logs of the running containers of the pod
+// that aren't streamed yet.
+func (t *tailer) startPod(ctx context.Context, pod *corev1.Pod) {
+	statuses := append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Running == nil || (t.container != nil && !t.container.MatchString(status.Name)) {
+			continue
+		}
+		key := pod.Namespace + "/" + pod.Name + "/" + status.ContainerID
+		t.mu.Lock()
+		if t.active[key] {
+			t.mu.Unlock()
+			continue
+		}
+		t.active[key] = true
+		t.mu.Unlock()
+		t.wg.Add(1)
+		go func(name, container string) {
+			defer t.wg.Done()
+			t.stream(ctx, pod.Namespace, name, container)
+			t.mu.Lock()
+			delete(t.active, key)
+			t.mu.Unlock()
+		}(pod.Name, status.Name)
+	}
+}
+
+// stream sends the logs of a container to the sources and waits until they
+// end.
+func (t *tailer) stream(ctx context.Context, namespace, pod, container string) {
+	opts := &corev1.PodLogOptions{Container: container, Follow: true}
+	if t.opts.Tail >= 0 {
+		opts.TailLines = &t.opts.Tail
+	}
+	r, err := t.client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("%s/%s: %v", pod, container, err)
+		}
+		return
+	}
+	defer r.Close()
+	name := pod + "/" + container
+	if t.opts.AllNamespaces {
+		name = namespace + "/" + name
+	}
+	done := make(chan struct{})
+	select {
+	case t.sources <- prettyjsonlog.Source{Name: name, Reader: &doneReader{ctx: ctx, r: r, done: done}}:
+		<-done
+	case <-ctx.Done():
+	}
+}
+
+// doneReader closes done when the stream ends, and reports the end of the
+// stream instead of an error once ctx is canceled.
+type doneReader struct {
+	ctx  context.Context
+	r    io.Reader
+	done chan struct{}
+	once sync.Once
+}
+
+func (d *doneReader) Read(b []byte) (int, error) {
+	n, err := d.r.Read(b)
+	if err != nil {
+		d.once.Do(func() { close(d.done) })
+		if d.ctx.Err() != nil {
+			err = io.EOF
+		}
+	}
+	return n, err
+}
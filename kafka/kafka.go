@@ -0,0 +1,89 @@
+// Package kafka reads the messages of Kafka topics as inputs of the
+// formatter, with kafka://broker/topic URLs. Importing it registers the
+// kafka scheme.
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func init() {
+	prettyjsonlog.RegisterStream("kafka", open)
+}
+
+// open returns the consumer of a kafka://broker1,broker2/topic URL. The
+// query sets the consumer group (group=dev), where to start without a
+// committed offset (from=beginning, the end by default), and meta=true adds
+// the partition and offset of the messages to the JSON lines as the
+// kafka_partition and kafka_offset fields.
+func open(u *url.URL) (prettyjsonlog.StreamConnect, error) {
+	topic := strings.Trim(u.Path, "/")
+	if u.Host == "" || topic == "" {
+		return nil, fmt.Errorf("expected kafka://broker/topic")
+	}
+	q := u.Query()
+	offset := kgo.NewOffset().AtEnd()
+	switch q.Get("from") {
+	case "", "end":
+	case "beginning":
+		offset = kgo.NewOffset().AtStart()
+	default:
+		return nil, fmt.Errorf("invalid from %q (available: beginning, end)", q.Get("from"))
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(strings.Split(u.Host, ",")...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(offset),
+	}
+	if group := q.Get("group"); group != "" {
+		opts = append(opts, kgo.ConsumerGroup(group))
+	}
+	meta := q.Get("meta") == "true"
+	return func(ctx context.Context, w io.Writer) (bool, error) {
+		client, err := kgo.NewClient(opts...)
+		if err != nil {
+			return false, err
+		}
+		defer client.Close()
+		received := false
+		for {
+			fetches := client.PollFetches(ctx)
+			if ctx.Err() != nil || fetches.IsClientClosed() {
+				return received, ctx.Err()
+			}
+			fetches.EachError(func(topic string, partition int32, err error) {
+				log.Printf("kafka %s/%d: %v", topic, partition, err)
+			})
+			var buf bytes.Buffer
+			fetches.EachRecord(func(r *kgo.Record) {
+				for _, line := range bytes.Split(bytes.TrimRight(r.Value, "\r\n"), []byte("\n")) {
+					if meta && bytes.HasPrefix(line, []byte("{")) {
+						buf.WriteString(`{"kafka_partition":` + strconv.Itoa(int(r.Partition)) + `,"kafka_offset":` + strconv.FormatInt(r.Offset, 10))
+						if rest := bytes.TrimSpace(line[1:]); !bytes.HasPrefix(rest, []byte("}")) {
+							buf.WriteByte(',')
+						}
+						line = line[1:]
+					}
+					buf.Write(line)
+					buf.WriteByte('\n')
+				}
+			})
+			if buf.Len() > 0 {
+				received = true
+				if _, err := w.Write(buf.Bytes()); err != nil {
+					return received, err
+				}
+			}
+		}
+	}, nil
+}
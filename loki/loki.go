@@ -0,0 +1,191 @@
+// Package loki streams the logs matching a Loki query to the formatter, with
+// the tail API of Loki.
+package loki
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"golang.org/x/net/websocket"
+)
+
+// Options selects the logs tailed.
+type Options struct {
+	// Addr is the URL of Loki, eg. http://localhost:3100. A user and
+	// password in the URL are sent with basic authentication.
+	Addr string
+	// Query is a LogQL log query, eg. {app="api"} |= "error".
+	Query string
+	// OrgID is the tenant of the logs in a multi-tenant Loki.
+	OrgID string
+	// Since is how far back the logs written before are shown.
+	Since time.Duration
+	// Limit is the maximum number of lines shown of the logs written before.
+	Limit int
+}
+
+// Run streams the logs matching the query until an interrupt signal is
+// received, reconnecting when the connection is lost. The lines are labeled
+// with the labels of their streams.
+func Run(p *prettyjsonlog.PrettyJsonLog, opts Options) error {
+	u, err := url.Parse(opts.Addr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid Loki address %q (eg. http://localhost:3100)", opts.Addr)
+	}
+	if opts.Query == "" {
+		return fmt.Errorf("a query is required (eg. --query '{app=\"api\"}')")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t := &tailer{
+		u:       u,
+		opts:    opts,
+		sources: make(chan prettyjsonlog.Source),
+		streams: map[string]*io.PipeWriter{},
+	}
+	// The first connection checks the address and the query before
+	// streaming.
+	ws, err := t.dial(ctx, time.Now().Add(-opts.Since))
+	if err != nil {
+		return err
+	}
+	go t.tail(ctx, ws)
+	return p.RunSources(t.sources, cancel)
+}
+
+type tailer struct {
+	u       *url.URL
+	opts    Options
+	sources chan prettyjsonlog.Source
+	// streams are the writers of the sources of the streams, by label.
+	streams map[string]*io.PipeWriter
+	// last is the time of the last line received, to resume from it.
+	last int64
+}
+
+// tailResponse is a message of the tail API.
+type tailResponse struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][]string        `json:"values"`
+	} `json:"streams"`
+	DroppedEntries []struct {
+		Labels    string `json:"labels"`
+		Timestamp string `json:"timestamp"`
+	} `json:"dropped_entries"`
+}
+
+// dial connects to the tail API, for the lines written from start.
+func (t *tailer) dial(ctx context.Context, start time.Time) (*websocket.Conn, error) {
+	target := *t.u
+	target.Scheme = strings.Replace(target.Scheme, "http", "ws", 1)
+	target.User = nil
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/loki/api/v1/tail"
+	q := url.Values{"query": {t.opts.Query}, "start": {strconv.FormatInt(start.UnixNano(), 10)}}
+	if t.opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(t.opts.Limit))
+	}
+	target.RawQuery = q.Encode()
+	config, err := websocket.NewConfig(target.String(), t.u.Scheme+"://"+t.u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if t.u.User != nil {
+		password, _ := t.u.User.Password()
+		config.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(t.u.User.Username()+":"+password)))
+	}
+	if t.opts.OrgID != "" {
+		config.Header.Set("X-Scope-OrgID", t.opts.OrgID)
+	}
+	return config.DialContext(ctx)
+}
+
+// tail sends the lines received to the sources of their streams until ctx
+// is canceled, reconnecting with backoff. Then it closes the sources.
+func (t *tailer) tail(ctx context.Context, ws *websocket.Conn) {
+	defer func() {
+		for _, w := range t.streams {
+			w.Close()
+		}
+		close(t.sources)
+	}()
+	delay := time.Second
+	for {
+		received, err := t.receive(ctx, ws)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			delay = time.Second
+		}
+		for {
+			log.Printf("loki: %v, reconnecting in %s", err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(2*delay, 30*time.Second)
+			if ws, err = t.dial(ctx, time.Unix(0, t.last+1)); err == nil {
+				break
+			}
+		}
+	}
+}
+
+// receive sends the lines received on ws until the connection ends or ctx
+// is canceled.
+func (t *tailer) receive(ctx context.Context, ws *websocket.Conn) (bool, error) {
+	defer ws.Close()
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+	received := false
+	for {
+		var resp tailResponse
+		if err := websocket.JSON.Receive(ws, &resp); err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("connection closed")
+			}
+			return received, err
+		}
+		received = true
+		for _, dropped := range resp.DroppedEntries {
+			log.Printf("loki: dropped a line of %s", dropped.Labels)
+		}
+		for _, stream := range resp.Streams {
+			w := t.stream(prettyjsonlog.LokiLabel(stream.Stream))
+			for _, v := range stream.Values {
+				if len(v) < 2 {
+					continue
+				}
+				if ts, err := strconv.ParseInt(v[0], 10, 64); err == nil {
+					t.last = max(t.last, ts)
+				}
+				if _, err := io.WriteString(w, strings.TrimRight(v[1], "\r\n")+"\n"); err != nil {
+					return received, err
+				}
+			}
+		}
+	}
+}
+
+// stream returns the writer of the source of a stream, starting it the
+// first time.
+func (t *tailer) stream(name string) *io.PipeWriter {
+	w, ok := t.streams[name]
+	if !ok {
+		var r *io.PipeReader
+		r, w = io.Pipe()
+		t.streams[name] = w
+		t.sources <- prettyjsonlog.Source{Name: name, Reader: r}
+	}
+	return w
+}
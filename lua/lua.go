@@ -0,0 +1,131 @@
+// Package lua runs the Lua scripts of --script file.lua, defining an
+// on_line(record) function called with the fields of each line. Importing
+// it registers the .lua script engine.
+package lua
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func init() {
+	prettyjsonlog.RegisterScriptEngine(".lua", Load)
+}
+
+// script is a loaded Lua script. A Lua state can't be used concurrently,
+// the lines are passed to on_line one at a time.
+type script struct {
+	mu     sync.Mutex
+	state  *lua.LState
+	onLine lua.LValue
+}
+
+// Load runs the Lua script at path, which must define a global on_line
+// function. on_line is given the fields of a line as a table, which it can
+// change in place or replace by returning another table, and returns false
+// to drop the line.
+func Load(path string) (prettyjsonlog.Script, error) {
+	state := lua.NewState()
+	if err := state.DoFile(path); err != nil {
+		state.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	onLine := state.GetGlobal("on_line")
+	if onLine.Type() != lua.LTFunction {
+		state.Close()
+		return nil, fmt.Errorf("%s: no on_line function", path)
+	}
+	return &script{state: state, onLine: onLine}, nil
+}
+
+func (s *script) OnLine(fields map[string]interface{}) (map[string]interface{}, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := toLua(s.state, fields).(*lua.LTable)
+	if err := s.state.CallByParam(lua.P{Fn: s.onLine, NRet: 1, Protect: true}, record); err != nil {
+		return nil, false, err
+	}
+	ret := s.state.Get(-1)
+	s.state.Pop(1)
+	switch ret := ret.(type) {
+	case *lua.LTable:
+		record = ret
+	case lua.LBool:
+		if !ret {
+			return nil, false, nil
+		}
+	}
+	m, ok := fromLua(record).(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+	}
+	return m, true, nil
+}
+
+func (s *script) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Close()
+	return nil
+}
+
+// toLua converts a decoded JSON value to a Lua value.
+func toLua(state *lua.LState, v interface{}) lua.LValue {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		t := state.NewTable()
+		for k, e := range v {
+			t.RawSetString(k, toLua(state, e))
+		}
+		return t
+	case []interface{}:
+		t := state.CreateTable(len(v), 0)
+		for _, e := range v {
+			t.Append(toLua(state, e))
+		}
+		return t
+	case string:
+		return lua.LString(v)
+	case float64:
+		return lua.LNumber(v)
+	case bool:
+		return lua.LBool(v)
+	}
+	return lua.LNil
+}
+
+// fromLua converts a Lua value to a JSON value. The tables with keys 1 to n
+// only are arrays, the others objects.
+func fromLua(v lua.LValue) interface{} {
+	switch v := v.(type) {
+	case *lua.LTable:
+		if n := v.MaxN(); n > 0 && n == countKeys(v) {
+			arr := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				arr = append(arr, fromLua(v.RawGetInt(i)))
+			}
+			return arr
+		}
+		m := map[string]interface{}{}
+		v.ForEach(func(k, e lua.LValue) {
+			m[k.String()] = fromLua(e)
+		})
+		return m
+	case lua.LString:
+		return string(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LBool:
+		return bool(v)
+	}
+	return nil
+}
+
+func countKeys(t *lua.LTable) int {
+	n := 0
+	t.ForEach(func(lua.LValue, lua.LValue) { n++ })
+	return n
+}
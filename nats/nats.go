@@ -0,0 +1,117 @@
+// Package nats reads the messages published on NATS subjects or stored in
+// JetStream streams as inputs of the formatter, with nats://server/subject
+// URLs. Importing it registers the nats scheme.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func init() {
+	prettyjsonlog.RegisterStream("nats", open)
+}
+
+// open returns the subscriber of a nats://[user:password@]server/subject
+// URL, the subject may have wildcards (eg. logs.>). With stream=NAME in the
+// query the messages of a JetStream stream are read instead, filtered by the
+// subject if any, from the new ones or with from=beginning all of them.
+func open(u *url.URL) (prettyjsonlog.StreamConnect, error) {
+	server := (&url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}).String()
+	subject := strings.Trim(u.Path, "/")
+	q := u.Query()
+	stream := q.Get("stream")
+	if u.Host == "" || (subject == "" && stream == "") {
+		return nil, fmt.Errorf("expected nats://server/subject or nats://server?stream=name")
+	}
+	deliver := jetstream.DeliverNewPolicy
+	switch q.Get("from") {
+	case "", "end":
+	case "beginning":
+		deliver = jetstream.DeliverAllPolicy
+	default:
+		return nil, fmt.Errorf("invalid from %q (available: beginning, end)", q.Get("from"))
+	}
+	return func(ctx context.Context, w io.Writer) (bool, error) {
+		nc, err := nats.Connect(server,
+			nats.Name("pretty-json-log"),
+			nats.MaxReconnects(-1),
+			nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
+				log.Printf("nats: %v", err)
+			}))
+		if err != nil {
+			return false, err
+		}
+		defer nc.Close()
+		if stream != "" {
+			return readStream(ctx, nc, stream, subject, deliver, w)
+		}
+		msgs := make(chan *nats.Msg, 1024)
+		sub, err := nc.ChanSubscribe(subject, msgs)
+		if err != nil {
+			return false, err
+		}
+		defer sub.Unsubscribe()
+		received := false
+		for {
+			select {
+			case <-ctx.Done():
+				return received, ctx.Err()
+			case msg := <-msgs:
+				received = true
+				if err := writeMessage(w, msg.Data); err != nil {
+					return received, err
+				}
+			}
+		}
+	}, nil
+}
+
+// readStream reads the messages of a JetStream stream with an ordered
+// consumer, which doesn't need acknowledgements.
+func readStream(ctx context.Context, nc *nats.Conn, stream, subject string, deliver jetstream.DeliverPolicy, w io.Writer) (bool, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return false, err
+	}
+	cfg := jetstream.OrderedConsumerConfig{DeliverPolicy: deliver}
+	if subject != "" {
+		cfg.FilterSubjects = []string{subject}
+	}
+	consumer, err := js.OrderedConsumer(ctx, stream, cfg)
+	if err != nil {
+		return false, err
+	}
+	it, err := consumer.Messages()
+	if err != nil {
+		return false, err
+	}
+	defer it.Stop()
+	stop := context.AfterFunc(ctx, it.Stop)
+	defer stop()
+	received := false
+	for {
+		msg, err := it.Next()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if err := writeMessage(w, msg.Data()); err != nil {
+			return received, err
+		}
+	}
+}
+
+// writeMessage writes the payload of a message as one or more lines.
+func writeMessage(w io.Writer, data []byte) error {
+	_, err := io.WriteString(w, strings.TrimRight(string(data), "\r\n")+"\n")
+	return err
+}
@@ -0,0 +1,128 @@
+// Package otlp receives the OpenTelemetry logs in the protobuf encoding of
+// OTLP, over gRPC and HTTP, for the listen option.
+package otlp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	prettyjsonlog.RegisterOTLPReceiver(receiver{})
+}
+
+type receiver struct{}
+
+func (receiver) DecodeLogs(body []byte) ([]byte, error) {
+	var req collogspb.ExportLogsServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	return encodeLogs(&req)
+}
+
+func (receiver) NewGRPCServer(export func(data []byte)) prettyjsonlog.GRPCServer {
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, &logsService{export: export})
+	return srv
+}
+
+type logsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	export func(data []byte)
+}
+
+func (s *logsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	data, err := encodeLogs(req)
+	if err != nil {
+		return nil, err
+	}
+	s.export(data)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// encodeLogs returns the OTLP JSON encoding of a request: the 64 bit
+// integers are strings, the enums numbers and the trace and span IDs hex.
+func encodeLogs(req *collogspb.ExportLogsServiceRequest) ([]byte, error) {
+	resourceLogs := make([]interface{}, 0, len(req.GetResourceLogs()))
+	for _, rl := range req.GetResourceLogs() {
+		scopeLogs := make([]interface{}, 0, len(rl.GetScopeLogs()))
+		for _, sl := range rl.GetScopeLogs() {
+			records := make([]interface{}, 0, len(sl.GetLogRecords()))
+			for _, r := range sl.GetLogRecords() {
+				record := map[string]interface{}{
+					"timeUnixNano":         strconv.FormatUint(r.GetTimeUnixNano(), 10),
+					"observedTimeUnixNano": strconv.FormatUint(r.GetObservedTimeUnixNano(), 10),
+					"severityNumber":       int32(r.GetSeverityNumber()),
+					"attributes":           keyValues(r.GetAttributes()),
+				}
+				if r.GetSeverityText() != "" {
+					record["severityText"] = r.GetSeverityText()
+				}
+				if r.GetEventName() != "" {
+					record["eventName"] = r.GetEventName()
+				}
+				if r.GetBody() != nil {
+					record["body"] = anyValue(r.GetBody())
+				}
+				if len(r.GetTraceId()) > 0 {
+					record["traceId"] = hex.EncodeToString(r.GetTraceId())
+				}
+				if len(r.GetSpanId()) > 0 {
+					record["spanId"] = hex.EncodeToString(r.GetSpanId())
+				}
+				records = append(records, record)
+			}
+			scopeLogs = append(scopeLogs, map[string]interface{}{
+				"scope":      map[string]interface{}{"name": sl.GetScope().GetName()},
+				"logRecords": records,
+			})
+		}
+		resourceLogs = append(resourceLogs, map[string]interface{}{
+			"resource":  map[string]interface{}{"attributes": keyValues(rl.GetResource().GetAttributes())},
+			"scopeLogs": scopeLogs,
+		})
+	}
+	return json.Marshal(map[string]interface{}{"resourceLogs": resourceLogs})
+}
+
+func keyValues(kvs []*commonpb.KeyValue) []interface{} {
+	res := make([]interface{}, 0, len(kvs))
+	for _, kv := range kvs {
+		res = append(res, map[string]interface{}{"key": kv.GetKey(), "value": anyValue(kv.GetValue())})
+	}
+	return res
+}
+
+func anyValue(v *commonpb.AnyValue) map[string]interface{} {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return map[string]interface{}{"stringValue": v.StringValue}
+	case *commonpb.AnyValue_BoolValue:
+		return map[string]interface{}{"boolValue": v.BoolValue}
+	case *commonpb.AnyValue_IntValue:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v.IntValue, 10)}
+	case *commonpb.AnyValue_DoubleValue:
+		return map[string]interface{}{"doubleValue": v.DoubleValue}
+	case *commonpb.AnyValue_BytesValue:
+		return map[string]interface{}{"bytesValue": base64.StdEncoding.EncodeToString(v.BytesValue)}
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, e := range v.ArrayValue.GetValues() {
+			values = append(values, anyValue(e))
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case *commonpb.AnyValue_KvlistValue:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": keyValues(v.KvlistValue.GetValues())}}
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,235 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// StatsOptions are the options of RunStats.
+type StatsOptions struct {
+	// By are the keys or paths of the fields the lines are grouped by.
+	By []string
+	// Duration is the key or path of a numeric or Go duration field (eg.
+	// "12.5ms") whose percentiles are shown per group, if not empty.
+	Duration string
+	// Top is the number of groups shown, the most frequent first, 0 for all.
+	Top int
+}
+
+// RunStats reads the logs like Run, and instead of showing them writes a
+// table of the number of lines, the error rate and the percentiles of the
+// duration field for each group of lines with the same values of the By
+// fields. The lines hidden by the filters aren't counted.
+func (p *PrettyJsonLog) RunStats(files []string, opts StatsOptions) error {
+	if len(opts.By) == 0 {
+		return errors.New("--by is required")
+	}
+	if p.config.TUI || p.config.Head > 0 || p.config.Tail > 0 {
+		return errors.New("the stats subcommand can't be used with --tui, --head or --tail")
+	}
+	p.aggregator = newAggregator(p, opts)
+	if err := p.Run(files); err != nil {
+		return err
+	}
+	return p.aggregator.print(os.Stdout)
+}
+
+// aggregator counts the lines shown by RunStats per group.
+type aggregator struct {
+	p    *PrettyJsonLog
+	opts StatsOptions
+	// errorSeverity is the severity of the error level, the lines at or
+	// above it are counted as errors, 0 if the levels have no error.
+	errorSeverity int
+
+	mu     sync.Mutex
+	groups map[string]*statsGroup
+	// durations is set once a duration field is a Go duration string, the
+	// percentiles are then shown as durations.
+	durations bool
+}
+
+type statsGroup struct {
+	values []string
+	count  int
+	errors int
+	// samples are the values of the duration field, in nanoseconds for the
+	// Go durations.
+	samples []float64
+}
+
+func newAggregator(p *PrettyJsonLog, opts StatsOptions) *aggregator {
+	a := &aggregator{p: p, opts: opts, groups: map[string]*statsGroup{}}
+	a.errorSeverity, _ = p.parseSeverity("error")
+	return a
+}
+
+// add counts a line in its group.
+func (a *aggregator) add(line *logLine) {
+	fields := line.decoded()
+	values := make([]string, len(a.opts.By))
+	for i, path := range a.opts.By {
+		values[i] = "-"
+		if v, ok := lookupPath(fields, path); ok {
+			values[i] = statsValue(v)
+		}
+	}
+	isError := false
+	if level, _ := line.level(); a.errorSeverity != 0 {
+		severity, ok := a.p.levelSeverity[level]
+		isError = ok && severity >= a.errorSeverity
+	}
+	var sample float64
+	hasSample, isDuration := false, false
+	if a.opts.Duration != "" {
+		if v, ok := lookupPath(fields, a.opts.Duration); ok {
+			sample, isDuration, hasSample = durationSample(v)
+		}
+	}
+
+	key := strings.Join(values, "\x00")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	g, ok := a.groups[key]
+	if !ok {
+		g = &statsGroup{values: values}
+		a.groups[key] = g
+	}
+	g.count++
+	if isError {
+		g.errors++
+	}
+	if hasSample {
+		g.samples = append(g.samples, sample)
+		a.durations = a.durations || isDuration
+	}
+}
+
+// statsValue renders a field value grouping the lines.
+func statsValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// durationSample returns the value of a duration field, and whether it's a
+// Go duration string in nanoseconds.
+func durationSample(v interface{}) (float64, bool, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, false, true
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, false, true
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return float64(d), true, true
+		}
+	}
+	return 0, false, false
+}
+
+// percentile returns the p-th percentile of sorted samples, by nearest rank.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// print writes the table of the groups, the most frequent first.
+func (a *aggregator) print(w io.Writer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groups := make([]*statsGroup, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return strings.Join(groups[i].values, "\x00") < strings.Join(groups[j].values, "\x00")
+	})
+	more := 0
+	if a.opts.Top > 0 && len(groups) > a.opts.Top {
+		more = len(groups) - a.opts.Top
+		groups = groups[:a.opts.Top]
+	}
+
+	header := append(append([]string(nil), a.opts.By...), "count", "errors")
+	if a.opts.Duration != "" {
+		header = append(header, "p50", "p95")
+	}
+	rows := [][]string{header}
+	for _, g := range groups {
+		row := append(append([]string(nil), g.values...), strconv.Itoa(g.count), fmt.Sprintf("%.1f%%", float64(g.errors)*100/float64(g.count)))
+		if a.opts.Duration != "" {
+			if len(g.samples) == 0 {
+				row = append(row, "-", "-")
+			} else {
+				sort.Float64s(g.samples)
+				row = append(row, a.formatSample(percentile(g.samples, 50)), a.formatSample(percentile(g.samples, 95)))
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	// The counts are aligned to the right, the values to the left.
+	numeric := len(a.opts.By)
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-ansi.StringWidth(cell))
+			if i >= numeric {
+				cell = pad + cell
+			} else if i < len(row)-1 {
+				cell += pad
+			}
+			if r == 0 {
+				cell = a.p.paint(a.p.theme.FieldKey, cell)
+			}
+			cells[i] = cell
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(cells, "  ")); err != nil {
+			return err
+		}
+	}
+	if more > 0 {
+		_, err := fmt.Fprintln(w, a.p.paint(a.p.theme.Time, fmt.Sprintf("… %d more groups", more)))
+		return err
+	}
+	return nil
+}
+
+func (a *aggregator) formatSample(v float64) string {
+	if a.durations {
+		return time.Duration(v).String()
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
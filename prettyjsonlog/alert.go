@@ -0,0 +1,105 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// alertInterval is the minimum time between two alerts, for a burst of
+// matching lines to ring once.
+const alertInterval = 2 * time.Second
+
+// severityKey is the field the severity of the level is compared in, for
+// the conditions like level >= error.
+const severityKey = "\x00severity"
+
+// alerter rings the terminal bell, and fires a desktop notification with
+// --alert-notify, when a line matching the --alert condition is shown.
+type alerter struct {
+	p      *PrettyJsonLog
+	when   [][]condition
+	notify bool
+
+	mu   sync.Mutex
+	last time.Time
+	// notifyErr logs the first error of the notifications.
+	notifyErr sync.Once
+}
+
+// newAlerter parses the --alert condition, with the syntax of the highlight
+// rules. The level is compared by its normalized name, or by severity with
+// >, >=, < and <=.
+func (p *PrettyJsonLog) newAlerter(when string, notify bool) (*alerter, error) {
+	alts, err := parseWhen(when)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert: %w", err)
+	}
+	for _, all := range alts {
+		for i, cond := range all {
+			if cond.path != "level" || cond.op == "==" || cond.op == "!=" || cond.op == "=~" {
+				continue
+			}
+			severity, err := p.parseSeverity(fmt.Sprint(cond.value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid alert: %w", err)
+			}
+			all[i].path, all[i].value = severityKey, float64(severity)
+		}
+	}
+	return &alerter{p: p, when: alts, notify: notify}, nil
+}
+
+// check alerts if the line matches, unless the last alert is too recent.
+func (a *alerter) check(line *logLine) {
+	fields := line.decoded()
+	if level, key := line.level(); key != "" {
+		fields[key] = level
+		fields["level"] = level
+		if severity, ok := a.p.levelSeverity[level]; ok {
+			fields[severityKey] = float64(severity)
+		}
+	}
+	if !matchWhen(a.when, fields) {
+		return
+	}
+	a.mu.Lock()
+	now := time.Now()
+	if now.Sub(a.last) < alertInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.last = now
+	a.mu.Unlock()
+
+	// The bell is rung on stderr, stdout may not be the terminal.
+	fmt.Fprint(os.Stderr, "\a")
+	if a.notify {
+		level, _ := line.level()
+		msg, _ := line.message()
+		go a.notifyDesktop("pretty-json-log: "+level, msg)
+	}
+}
+
+// notifyDesktop fires a desktop notification with notify-send on Linux and
+// osascript on macOS.
+func (a *alerter) notifyDesktop(title, msg string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", msg, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		a.notifyErr.Do(func() { log.Println("alert: desktop notifications are not supported on windows") })
+		return
+	default:
+		cmd = exec.Command("notify-send", title, msg)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		a.notifyErr.Do(func() { log.Printf("alert: %v %s", err, out) })
+	}
+}
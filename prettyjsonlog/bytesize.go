@@ -0,0 +1,57 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultByteFields are the fields shown as byte sizes when --byte-fields is
+// not set.
+var defaultByteFields = []string{"bytes", "size", "content_length", "bytes_in", "bytes_out", "bytes_sent", "body_bytes_sent", "response_size", "request_size"}
+
+// isByteField reports whether the value at path is a byte count, by path or
+// key.
+func (p *PrettyJsonLog) isByteField(path string) bool {
+	key := path[strings.LastIndex(path, ".")+1:]
+	for _, f := range p.config.ByteFields {
+		if f == path || f == key {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBytes renders a byte count according to the --bytes mode, eg. 1.4 MiB
+// or 1.4 MiB (1468006). Numbers that aren't integers are kept as is.
+func (p *PrettyJsonLog) formatBytes(n json.Number) string {
+	v, err := n.Int64()
+	if err != nil {
+		return n.String()
+	}
+	switch p.config.Bytes {
+	case "human":
+		return humanBytes(v)
+	case "both":
+		return fmt.Sprintf("%s (%s)", humanBytes(v), n)
+	}
+	return n.String()
+}
+
+// humanBytes formats a byte count with binary units.
+func humanBytes(n int64) string {
+	const unit = 1024
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := abs / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
@@ -0,0 +1,45 @@
+package prettyjsonlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// callerFields are the keys of the fields holding the source location of a
+// line, like zap's caller or the file of slog's source.
+var callerFields = map[string]bool{"caller": true, "source": true, "file": true, "src": true}
+
+var callerRe = regexp.MustCompile(`^((?:[A-Za-z]:)?[^\s:]*[/\\][^\s:]*?)((?::\d+){0,2})$`)
+
+// formatCaller renders a source location with a long path, like
+// /home/ci/go/src/example.com/app/pkg/server/handler.go:123, keeping the last
+// path segments (server/handler.go:123), coloring the line number and linking
+// it to the editor with --hyperlinks. It returns false if the field is not a
+// caller field or the value isn't a path.
+func (p *PrettyJsonLog) formatCaller(path, s string) (string, bool) {
+	shorten := !p.config.FullCaller && p.config.CallerSegments > 0
+	if (!shorten && !p.hyperlinks()) || !callerFields[path[strings.LastIndex(path, ".")+1:]] {
+		return "", false
+	}
+	m := callerRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	file := m[1]
+	segments := 0
+	for i := len(file) - 1; i >= 0 && shorten; i-- {
+		if file[i] == '/' || file[i] == '\\' {
+			segments++
+			if segments == p.config.CallerSegments {
+				file = file[i+1:]
+				break
+			}
+		}
+	}
+	rendered := p.highlightMatches(file, p.theme.String) + p.highlightMatches(m[2], p.theme.LineNumber)
+	if p.hyperlinks() {
+		pos := append(strings.Split(strings.TrimPrefix(m[2], ":"), ":"), "")
+		rendered = hyperlink(p.editorURL(m[1], pos[0], pos[1]), rendered)
+	}
+	return rendered, true
+}
@@ -0,0 +1,176 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// clefLevels are the levels of Serilog.
+var clefLevels = map[string]string{
+	"Verbose":     "trace",
+	"Debug":       "debug",
+	"Information": "info",
+	"Warning":     "warn",
+	"Error":       "error",
+	"Fatal":       "fatal",
+}
+
+// clefLine returns a line in the compact log event format of Serilog (CLEF),
+// with the time, level and message at the keys of the config. The message
+// is @m, or the message template @mt rendered with the properties of the
+// line, the exception @x is shown as a block below the line. It returns
+// false if the line isn't CLEF.
+func (p *PrettyJsonLog) clefLine(text string, line *logLine) (string, bool) {
+	_, hasTime := line.line["@t"]
+	_, hasMessage := line.line["@m"]
+	_, hasTemplate := line.line["@mt"]
+	if !hasTime || !(hasMessage || hasTemplate) {
+		return "", false
+	}
+	fields, err := splitObject([]byte(text))
+	if err != nil {
+		return "", false
+	}
+	var b bytes.Buffer
+	seen := map[string]bool{}
+	add := func(key string, raw json.RawMessage) {
+		if seen[key] || raw == nil {
+			return
+		}
+		seen[key] = true
+		k, _ := json.Marshal(key)
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(raw)
+	}
+	addString := func(key, s string) {
+		v, _ := json.Marshal(s)
+		add(key, v)
+	}
+	firstKey := func(keys string) string {
+		key, _, _ := strings.Cut(keys, ",")
+		return key
+	}
+	add(firstKey(p.config.TimeFieldKey), fields["@t"])
+	// The level is omitted for the Information ones.
+	level := "Information"
+	if raw, ok := fields["@l"]; ok {
+		json.Unmarshal(raw, &level)
+	}
+	if l, ok := clefLevels[level]; ok {
+		level = l
+	}
+	addString(firstKey(p.config.LevelFieldKey), level)
+	if raw, ok := fields["@m"]; ok {
+		add(firstKey(p.config.MessageFieldKey), raw)
+	} else {
+		var template string
+		var renderings []string
+		json.Unmarshal(fields["@mt"], &template)
+		json.Unmarshal(fields["@r"], &renderings)
+		addString(firstKey(p.config.MessageFieldKey), renderMessageTemplate(template, fields, renderings))
+	}
+	add("exception", fields["@x"])
+	add("event_id", fields["@i"])
+	add("trace_id", fields["@tr"])
+	add("span_id", fields["@sp"])
+	for _, key := range jsonKeyOrder([]byte(text))[""] {
+		switch {
+		case strings.HasPrefix(key, "@@"):
+			// The properties starting with @ are escaped with another one.
+			add(key[1:], fields[key])
+		case !strings.HasPrefix(key, "@"):
+			add(key, fields[key])
+		}
+	}
+	return "{" + b.String() + "}", true
+}
+
+// renderMessageTemplate substitutes the {Property} placeholders of a
+// Serilog message template with the values of the properties, the strings
+// quoted like Serilog does. The placeholders with a format, like
+// {Elapsed:0.00}, take the renderings of @r when present, in order, or else
+// the value as written in the line. The alignment of {Name,10} and
+// {Name,-10} pads the value. The placeholders of missing properties are
+// kept.
+func renderMessageTemplate(template string, fields map[string]json.RawMessage, renderings []string) string {
+	var sb strings.Builder
+	formatted := 0
+	for i := 0; i < len(template); {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"):
+			sb.WriteByte('{')
+			i += 2
+			continue
+		case strings.HasPrefix(template[i:], "}}"):
+			sb.WriteByte('}')
+			i += 2
+			continue
+		case template[i] != '{':
+			sb.WriteByte(template[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			sb.WriteString(template[i:])
+			break
+		}
+		token := template[i : i+end+1]
+		i += end + 1
+		// {@Name} and {$Name} destructure and stringify the value.
+		spec, _, hasFormat := strings.Cut(strings.TrimLeft(token[1:len(token)-1], "@$"), ":")
+		name, align, _ := strings.Cut(spec, ",")
+		raw, ok := fields[name]
+		var s string
+		if ok {
+			// The strings are quoted unless formatted, like with :l, the
+			// formatted values being mostly the dates and numbers.
+			s = templateValue(raw, !hasFormat)
+		}
+		if hasFormat {
+			formatted++
+			if formatted <= len(renderings) {
+				s, ok = renderings[formatted-1], true
+			}
+		}
+		if !ok {
+			sb.WriteString(token)
+			continue
+		}
+		if width, err := strconv.Atoi(strings.TrimSpace(align)); err == nil {
+			pad := strings.Repeat(" ", max(0, max(width, -width)-utf8.RuneCountInString(s)))
+			if width > 0 {
+				s = pad + s
+			} else {
+				s += pad
+			}
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+// templateValue renders the value of a property in a message template: the
+// strings quoted if quote is set, the other values as written in the line.
+func templateValue(raw json.RawMessage, quote bool) string {
+	var v interface{}
+	json.Unmarshal(raw, &v)
+	if s, ok := v.(string); ok {
+		if quote {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
@@ -0,0 +1,85 @@
+package prettyjsonlog
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// RunCommand runs the command given by args and pretty prints its stdout and
+// stderr, labeling the lines written to stderr (with the PTY option both go
+// to the PTY and can't be told apart). Signals received are forwarded to the
+// command. It returns the exit code of the command, and an error if it
+// succeeded but a line at or above the --fail-on level was read.
+func (p *PrettyJsonLog) RunCommand(args []string) (int, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	// The keys pressed in the terminal are for the command.
+	p.config.NoPause = true
+	inputs, err := p.startCommand(cmd)
+	if err != nil {
+		return 0, err
+	}
+	defer closeInputs(inputs)
+
+	err = p.process(inputs, true, func(sig os.Signal) {
+		cmd.Process.Signal(sig)
+	})
+	if p.config.TUI {
+		// The viewer was closed, the output of the command isn't read anymore.
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+	waitErr := cmd.Wait()
+	if err != nil {
+		return 0, err
+	}
+	code, err := exitCode(waitErr)
+	if err == nil && code == 0 {
+		err = p.failOnError()
+	}
+	return code, err
+}
+
+// startCommand starts cmd and returns the inputs its output is read from.
+func (p *PrettyJsonLog) startCommand(cmd *exec.Cmd) ([]input, error) {
+	name := cmd.Args[0]
+	if p.config.PTY {
+		out, err := startPTY(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return []input{{name: name, stream: "stdout", reader: out}}, nil
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return []input{
+		{name: name, stream: "stdout", reader: stdout},
+		{name: name, stream: "stderr", reader: stderr},
+	}, nil
+}
+
+// exitCode returns the exit code of a command given the error returned by
+// Wait, using the shell convention of 128+n for commands killed by signal n.
+func exitCode(err error) (int, error) {
+	var exitErr *exec.ExitError
+	if err == nil {
+		return 0, nil
+	}
+	if !errors.As(err, &exitErr) {
+		return 0, err
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
@@ -0,0 +1,51 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// popComponent removes the component field of the line and returns it as a
+// tag shown before the message, like "[db] ", in a color derived from its
+// value. It returns "" if the line has no component field.
+func (l *logLine) popComponent() string {
+	if l.p.config.ComponentField == "" {
+		return ""
+	}
+	for _, key := range strings.Split(l.p.config.ComponentField, ",") {
+		v := l.getInterfaceField(key, nil)
+		switch v.(type) {
+		case string, float64:
+		default:
+			continue
+		}
+		name := fmt.Sprint(v)
+		if name == "" {
+			continue
+		}
+		l.deleteField(key)
+		if l.p.config.ComponentLength > 0 {
+			name = abbreviateComponent(name, l.p.config.ComponentLength)
+		}
+		return l.p.paint(l.p.stableColor(name), "["+name+"]") + " "
+	}
+	return ""
+}
+
+// abbreviateComponent shortens the segments of a dotted name to their first
+// letter, from the left, until it fits in length. The last segment is kept,
+// so the result can be longer than length.
+func abbreviateComponent(name string, length int) string {
+	if len(name) <= length {
+		return name
+	}
+	segments := strings.Split(name, ".")
+	excess := len(name) - length
+	for i := 0; i < len(segments)-1 && excess > 0; i++ {
+		if len(segments[i]) > 1 {
+			excess -= len(segments[i]) - 1
+			segments[i] = segments[i][:1]
+		}
+	}
+	return strings.Join(segments, ".")
+}
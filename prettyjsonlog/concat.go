@@ -0,0 +1,51 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// splitConcatenated splits a line made of several JSON objects, like
+// {"a":1}{"b":2} when writers race. It returns nil if the line isn't made of
+// at least two objects.
+func splitConcatenated(text string) []string {
+	if !strings.HasPrefix(strings.TrimSpace(text), "{") {
+		return nil
+	}
+	d := json.NewDecoder(strings.NewReader(text))
+	var parts []string
+	for {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil || len(raw) == 0 || raw[0] != '{' {
+			return nil
+		}
+		parts = append(parts, string(raw))
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+	return parts
+}
+
+// formatConcatenated formats the objects of a concatenated line as separate
+// lines. The level returned is the most severe one.
+func (p *PrettyJsonLog) formatConcatenated(entry logEntry, parts []string) (string, string, bool) {
+	var out strings.Builder
+	level, shown := "", false
+	for _, part := range parts {
+		entry.text = part
+		s, l, ok := p.formatEntry(entry)
+		if !ok {
+			continue
+		}
+		out.WriteString(s)
+		if !shown || p.levelSeverity[l] > p.levelSeverity[level] {
+			level = l
+		}
+		shown = true
+	}
+	return out.String(), level, shown
+}
@@ -0,0 +1,319 @@
+package prettyjsonlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type PrettyJsonLogConfig struct {
+	TimeFieldKey    string `yaml:"time-field"`
+	LevelFieldKey   string `yaml:"level-field"`
+	MessageFieldKey string `yaml:"message-field"`
+	OutputTimeFmt   string `yaml:"time-format"`
+	// TimeLayouts are the layouts of the time field, tried in order instead
+	// of guessing the layout of each time. They are Go layouts, names of the
+	// layouts of the time package (eg. RFC3339) or strptime patterns.
+	TimeLayouts []string `yaml:"time-layout"`
+	// Since and Until only show the lines in a time range. They are times
+	// or durations before now (eg. 15m, 2d).
+	Since string `yaml:"since"`
+	Until string `yaml:"until"`
+	// EpochUnit is the unit of numeric timestamps: auto (the default) to
+	// guess it from their magnitude, s, ms, us or ns.
+	EpochUnit string `yaml:"epoch-unit"`
+	// Timezone is the timezone times are shown in: local (the default),
+	// original to keep the timezone of the log line, or a name like UTC or
+	// America/New_York.
+	Timezone string `yaml:"timezone"`
+	// TimeDelta shows the time elapsed since the previous line after the
+	// time, highlighted when above DeltaThreshold. Gap shows a separator
+	// between the lines further apart. WarnSkew colors the times earlier
+	// than the previous one or in the future by more than it.
+	TimeDelta      bool          `yaml:"time-delta"`
+	DeltaThreshold time.Duration `yaml:"delta-threshold"`
+	Gap            time.Duration `yaml:"gap"`
+	WarnSkew       time.Duration `yaml:"warn-skew"`
+	MinLevel       string        `yaml:"min-level"`
+	FailOn         string        `yaml:"fail-on"`
+	Filter         string        `yaml:"filter"`
+	Grep           string        `yaml:"grep"`
+	GrepV          string        `yaml:"grep-v"`
+	GrepRaw        bool          `yaml:"grep-raw"`
+	Prefix         bool          `yaml:"prefix"`
+	NoPrefix       bool          `yaml:"no-prefix"`
+	StripPrefix    bool          `yaml:"strip-prefix"`
+	PrefixLabel    bool          `yaml:"prefix-label"`
+	Follow         bool          `yaml:"follow"`
+	NoExitOnEOF    bool          `yaml:"no-exit-on-eof"`
+	TUI            bool          `yaml:"tui"`
+	NoPause        bool          `yaml:"no-pause"`
+	PTY            bool          `yaml:"pty"`
+	MaxLineSize    int           `yaml:"max-line-size"`
+	Summary        bool          `yaml:"summary"`
+	Footer         bool          `yaml:"footer"`
+	// Workers is the number of lines parsed and formatted concurrently,
+	// shown in their original order.
+	Workers int `yaml:"workers"`
+	// BufferSize is the number of lines read ahead of the output, and
+	// DropPolicy what happens when they are all waiting: block (the
+	// default) to stop reading, drop-oldest or drop-new to drop lines.
+	BufferSize int    `yaml:"buffer-size"`
+	DropPolicy string `yaml:"drop-policy"`
+	// Head only shows the first lines, and Tail the last ones once the
+	// inputs are read.
+	Head int `yaml:"head"`
+	Tail int `yaml:"tail"`
+
+	// Listen are addresses to receive the logs from instead of reading files,
+	// like udp://0.0.0.0:5514, tcp://:5514 or http://:3100.
+	Listen []string `yaml:"listen"`
+	// Inputs are read after the files given as arguments: files or stream
+	// URLs like ws://localhost:8080/logs.
+	Inputs []string `yaml:"input"`
+	// Headers are sent with the requests of the http:// and https:// inputs,
+	// like "Authorization: Bearer token".
+	Headers []string `yaml:"header"`
+
+	// Out is a file the rendered lines are also written to, with colors if
+	// OutColor is always (never by default).
+	Out      string `yaml:"out"`
+	OutColor string `yaml:"out-color"`
+
+	// Sink stores the parsed lines while they are shown, as kind:path (eg.
+	// sqlite:session.db).
+	Sink string `yaml:"sink"`
+
+	// Output is the output format: text (the default) or json to write the
+	// lines left after filtering as compact JSON, one per line.
+	Output string `yaml:"output"`
+
+	// Format is a text/template used to render the lines instead of the
+	// default layout, eg. '{{.time}} [{{.level}}] {{.msg}} {{.fields.trace_id}}'.
+	Format string `yaml:"format"`
+
+	// Redact replaces the values of fields like password or token, and JWTs,
+	// AWS access keys and credit card numbers with [REDACTED]. RedactKeys and
+	// RedactValues are additional regexes for the keys and values redacted.
+	Redact       bool     `yaml:"redact"`
+	RedactKeys   []string `yaml:"redact-key"`
+	RedactValues []string `yaml:"redact-value"`
+
+	// Alert rings the terminal bell when a line matching this condition is
+	// shown (eg. level >= error), with a desktop notification if
+	// AlertNotify is set.
+	Alert       string `yaml:"alert"`
+	AlertNotify bool   `yaml:"alert-notify"`
+	// Exec is a shell command run for each line shown matching the jq
+	// filter ExecOnMatch, with the line on stdin and the placeholders
+	// {json}, {level}, {msg} and {time} replaced by its values.
+	ExecOnMatch string `yaml:"exec-on-match"`
+	Exec        string `yaml:"exec"`
+	// TransformCmd is a shell command the parsed lines are piped through
+	// before being shown, writing a JSON line replacing each line it reads
+	// (or an empty line or null to drop it), started for each worker.
+	TransformCmd string `yaml:"transform-cmd"`
+	// Parsers parse the lines that are neither JSON nor logfmt, tried in
+	// order, and Renderer renders the lines instead of the default layout:
+	// plugins registered by name, or exec:command for a command reading and
+	// writing JSON lines.
+	Parsers  []string `yaml:"parser"`
+	Renderer string   `yaml:"renderer"`
+
+	// Patterns are the parsers of the non-JSON lines defined by name, for
+	// Parsers: grok patterns like '%{IP:client} %{WORD:method}' or regexes
+	// with named groups, whose captures are the fields of the lines.
+	// GrokPatterns are the patterns added to the built-in ones for them, by
+	// name.
+	Patterns     map[string]string `yaml:"patterns"`
+	GrokPatterns map[string]string `yaml:"grok-patterns"`
+
+	// Script is a script changing the fields of the lines before they're
+	// shown, like a Lua script (.lua) defining on_line(record).
+	Script string `yaml:"script"`
+
+	// Webhook is a URL the lines shown matching the jq filter
+	// WebhookOnMatch are posted to, at most WebhookRate of them (eg. 10/m,
+	// the default). The payload is WebhookTemplate, a --format template,
+	// by default a message for the Slack and Discord webhooks and the line
+	// as is for the others.
+	Webhook         string `yaml:"webhook"`
+	WebhookOnMatch  string `yaml:"webhook-on-match"`
+	WebhookRate     string `yaml:"webhook-rate"`
+	WebhookTemplate string `yaml:"webhook-template"`
+
+	// HighlightRules color the values of fields matching a condition, eg.
+	// status >= 500 in red.
+	HighlightRules []HighlightRule `yaml:"highlight-rules"`
+	// Highlight colors the parts of the message and values matching these
+	// regexes, optionally followed by @ and a color spec.
+	Highlight []string `yaml:"highlight"`
+
+	// NoHTTPColors disables the colors of the status codes, methods, paths
+	// and client addresses of HTTP access logs.
+	NoHTTPColors bool `yaml:"no-http-colors"`
+
+	// CallerSegments is the number of path segments kept of the caller, source
+	// and file fields holding a long path (2 by default, eg.
+	// server/handler.go:123). FullCaller shows the full path.
+	CallerSegments int  `yaml:"caller-segments"`
+	FullCaller     bool `yaml:"full-caller"`
+
+	// Hyperlinks renders the caller fields as links to EditorURL and the
+	// TraceFields as links to TraceURL when writing colors to a terminal.
+	// EditorURL is a template with {path}, {line} and {col} (vscode://file
+	// by default), TraceURL with {id} (eg. http://localhost:16686/trace/{id}).
+	Hyperlinks  bool     `yaml:"hyperlinks"`
+	EditorURL   string   `yaml:"editor-url"`
+	TraceURL    string   `yaml:"trace-url"`
+	TraceFields []string `yaml:"trace-fields"`
+
+	// GroupBy is the key or dot separated path of a field like trace_id. The
+	// lines sharing its value are indented under the first one and the value
+	// is given a color.
+	GroupBy string `yaml:"group-by"`
+
+	// ComponentField is the key of a field like logger, component or service
+	// (or comma separated keys, the first one found is used) shown as a tag
+	// before the message, in a color derived from its value.
+	ComponentField string `yaml:"component-field"`
+	// ComponentLength abbreviates the dotted components, like the names of
+	// Java loggers, to about this length as Logback does: the packages are
+	// shortened to their first letter from the left, the class name is kept
+	// (eg. c.e.s.UserService). 0 keeps them.
+	ComponentLength int `yaml:"component-length"`
+
+	// Rename shows fields under other names, by key or dot separated path of
+	// a nested field (eg. "http.request.method": method). Nested fields are
+	// moved to the top level.
+	Rename map[string]string `yaml:"rename"`
+	// FieldOrder is the order of the fields: sorted (the default) by key or
+	// original to keep the order of the log line.
+	FieldOrder string `yaml:"field-order"`
+	// PriorityFields are shown first, in this order, before the other fields
+	// sorted by key.
+	PriorityFields []string `yaml:"priority-fields"`
+	// HiddenFields are the keys of the fields not shown, or prefixes of keys
+	// when ending with "*" (eg. _*).
+	HiddenFields []string `yaml:"hide-fields"`
+
+	// ParseNestedJSON shows the string values holding JSON objects or arrays
+	// as nested values.
+	ParseNestedJSON bool `yaml:"parse-nested-json"`
+
+	// Flatten shows the values nested in objects as separate fields with dot
+	// separated keys.
+	Flatten bool `yaml:"flatten"`
+
+	// MaxDepth collapses the objects and arrays nested deeper than this
+	// (the top level fields are at depth 1), 0 means unlimited.
+	MaxDepth int `yaml:"max-depth"`
+
+	// MaxValueLength truncates the string values longer than this, 0 means
+	// unlimited. MaxValueLengths overrides it for some fields, by key or
+	// dot separated path (eg. "sql": 200, "req.body": 0).
+	MaxValueLength  int            `yaml:"max-value-length"`
+	MaxValueLengths map[string]int `yaml:"max-value-lengths"`
+
+	// Bytes is how the byte counts in ByteFields (by key or dot separated
+	// path) are shown: raw (the default), human (1.4 MiB) or both (1.4 MiB
+	// (1468006)).
+	Bytes      string   `yaml:"bytes"`
+	ByteFields []string `yaml:"byte-fields"`
+
+	// Wrap wraps the fields of the lines wider than the terminal (or Width)
+	// onto indented lines, Truncate cuts them.
+	Wrap     bool `yaml:"wrap"`
+	Truncate bool `yaml:"truncate"`
+	Width    int  `yaml:"width"`
+
+	// Expand shows the objects and arrays with more than ExpandSize values or
+	// nested deeper than ExpandDepth as indented JSON below the line.
+	Expand      bool `yaml:"expand"`
+	ExpandSize  int  `yaml:"expand-size"`
+	ExpandDepth int  `yaml:"expand-depth"`
+
+	// NonJSON is how lines that can't be parsed are shown: plain (the
+	// default), dim, marker or hide.
+	NonJSON     string `yaml:"non-json"`
+	SkipNonJSON bool   `yaml:"skip-non-json"`
+	ExtractJSON bool   `yaml:"extract-json"`
+
+	// Sample keeps n out of every m lines below WARN (eg. 1/100) and
+	// RateLimit limits their rate (eg. 200/s, 1000/m).
+	Sample    string `yaml:"sample"`
+	RateLimit string `yaml:"rate-limit"`
+
+	// Dedupe collapses consecutive lines with the same message and fields,
+	// or the same DedupeKeys, within DedupeWindow of the first one.
+	Dedupe       bool          `yaml:"dedupe"`
+	DedupeKeys   []string      `yaml:"dedupe-keys"`
+	DedupeWindow time.Duration `yaml:"dedupe-window"`
+
+	// Preset selects the field keys and levels of a known logger. Presets
+	// defines additional presets or overrides the built-in ones.
+	Preset  string            `yaml:"preset"`
+	Presets map[string]Preset `yaml:"presets"`
+	// Levels maps numeric levels to level names, overriding or extending the
+	// levels of the preset (eg. syslog severities 0-7).
+	Levels map[int]string `yaml:"levels"`
+	// LevelAliases maps level names to the canonical ones, in addition to the
+	// aliases of the preset (eg. critical: fatal).
+	LevelAliases map[string]string `yaml:"level-aliases"`
+	// CustomLevels defines levels other than the standard ones, by name.
+	CustomLevels map[string]CustomLevel `yaml:"custom-levels"`
+
+	// Theme selects one of the built-in color themes. Colors overrides the
+	// color of individual elements of the theme, keys are element names (eg.
+	// "time", "field-key") or log levels (eg. "ERROR"), values are color specs
+	// like "hi-white bold bg-red".
+	Theme  string            `yaml:"theme"`
+	Colors map[string]string `yaml:"colors"`
+	// Color is one of auto (the default), always or never.
+	Color string `yaml:"color"`
+}
+
+// CustomLevel is a level like AUDIT or SECURITY. Severity places it among the
+// standard levels for --min-level (TRACE is 10, DEBUG 20, INFO 30, WARN 40,
+// ERROR 50, FATAL 60 and PANIC 70).
+type CustomLevel struct {
+	Severity int    `yaml:"severity"`
+	Color    string `yaml:"color"`
+}
+
+// DefaultConfigPath returns the config file used when --config is not given.
+func DefaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "pretty-json-log", "config.yaml")
+}
+
+// LoadConfigFile reads the YAML config file at path into config. Only the
+// settings present in the file are overwritten. A missing file is not an
+// error unless mustExist is set.
+func LoadConfigFile(path string, config *PrettyJsonLogConfig, mustExist bool) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !mustExist {
+			return nil
+		}
+		return err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
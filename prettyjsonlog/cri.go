@@ -0,0 +1,96 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// criLineRe matches the lines of the CRI container log format used by
+// Kubernetes nodes: `TIMESTAMP STREAM TAG MESSAGE` where TAG is P for a
+// partial line and F for the last part of a line.
+var criLineRe = regexp.MustCompile(`^(\d{4}-\d\d-\d\dT\S+) (stdout|stderr) ([PF]) (.*)$`)
+
+type criLine struct {
+	time    time.Time
+	stream  string
+	partial bool
+	text    string
+}
+
+// parseCRI parses a line of a CRI container log, or of the json-file log of
+// Docker like {"log":"message\n","stream":"stdout","time":"..."}.
+func parseCRI(text string) (criLine, bool) {
+	if strings.HasPrefix(text, `{"log":`) {
+		return parseDockerJSON(text)
+	}
+	m := criLineRe.FindStringSubmatch(text)
+	if m == nil {
+		return criLine{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, m[1])
+	if err != nil {
+		return criLine{}, false
+	}
+	return criLine{time: t, stream: m[2], partial: m[3] == "P", text: m[4]}, true
+}
+
+// parseDockerJSON parses a line of the json-file log of Docker. The lines
+// without a trailing newline are partial. Only the objects with exactly the
+// log, stream and time keys are Docker lines, an application logging a log
+// field first isn't unwrapped.
+func parseDockerJSON(text string) (criLine, bool) {
+	fields, err := splitObject([]byte(text))
+	if err != nil || len(fields) != 3 {
+		return criLine{}, false
+	}
+	var log, stream, ts string
+	if json.Unmarshal(fields["log"], &log) != nil || json.Unmarshal(fields["stream"], &stream) != nil || json.Unmarshal(fields["time"], &ts) != nil {
+		return criLine{}, false
+	}
+	if stream != "stdout" && stream != "stderr" {
+		return criLine{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return criLine{}, false
+	}
+	msg := strings.TrimSuffix(log, "\n")
+	return criLine{time: t, stream: stream, partial: msg == log, text: strings.TrimSuffix(msg, "\r")}, true
+}
+
+// criJoiner joins the partial lines of a CRI or Docker log back into full
+// lines.
+type criJoiner struct {
+	partial map[string]string
+}
+
+// join returns the full line for text and whether it is complete. Lines
+// which are not in the CRI format are returned as is.
+func (j *criJoiner) join(text string) (string, bool) {
+	cri, ok := parseCRI(text)
+	if !ok {
+		return text, true
+	}
+	if cri.partial {
+		if j.partial == nil {
+			j.partial = map[string]string{}
+		}
+		j.partial[cri.stream] += cri.text
+		return "", false
+	}
+	if rest, ok := j.partial[cri.stream]; ok {
+		delete(j.partial, cri.stream)
+		return cri.time.Format(time.RFC3339Nano) + " " + cri.stream + " F " + rest + cri.text, true
+	}
+	return text, true
+}
+
+// streamLabel renders the name of an output stream, highlighting stderr.
+func (p *PrettyJsonLog) streamLabel(stream string) string {
+	if stream == "stderr" {
+		return p.paint(p.theme.Stderr, stream)
+	}
+	return p.paint(p.theme.Source, stream)
+}
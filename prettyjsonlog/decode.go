@@ -0,0 +1,323 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// errNotObject is returned by splitObject for the lines that aren't a JSON
+// object.
+var errNotObject = errors.New("not a JSON object")
+
+// splitObject parses a JSON object in a single pass, validating it, and
+// returns its fields as raw values sliced from data. The values are only
+// decoded when they are needed, by decodeRaw.
+func splitObject(data []byte) (map[string]json.RawMessage, error) {
+	s := scanner{data: data}
+	s.skipSpace()
+	if !s.consume('{') {
+		return nil, errNotObject
+	}
+	fields := map[string]json.RawMessage{}
+	s.skipSpace()
+	if !s.consume('}') {
+		for {
+			s.skipSpace()
+			start := s.pos
+			if err := s.skipString(); err != nil {
+				return nil, err
+			}
+			key, err := unquote(data[start:s.pos])
+			if err != nil {
+				return nil, err
+			}
+			s.skipSpace()
+			if !s.consume(':') {
+				return nil, s.errorf("expected ':'")
+			}
+			s.skipSpace()
+			start = s.pos
+			if _, err := s.value(0); err != nil {
+				return nil, err
+			}
+			fields[key] = data[start:s.pos:s.pos]
+			s.skipSpace()
+			if s.consume('}') {
+				break
+			}
+			if !s.consume(',') {
+				return nil, s.errorf("expected ',' or '}'")
+			}
+		}
+	}
+	s.skipSpace()
+	if s.pos != len(data) {
+		return nil, s.errorf("unexpected data after the object")
+	}
+	return fields, nil
+}
+
+// maxNesting bounds the depth of the values, like encoding/json.
+const maxNesting = 10000
+
+type scanner struct {
+	data []byte
+	pos  int
+	// decode tells value to decode the values instead of only validating
+	// them, with the numbers as json.Number if useNumber is set.
+	decode    bool
+	useNumber bool
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSON at offset %d: %s", s.pos, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// value parses the value at the current position, and decodes it like
+// json.Unmarshal into an interface{} if s.decode is set.
+func (s *scanner) value(depth int) (interface{}, error) {
+	if depth > maxNesting {
+		return nil, s.errorf("exceeded max depth")
+	}
+	if s.pos >= len(s.data) {
+		return nil, s.errorf("unexpected end")
+	}
+	switch c := s.data[s.pos]; {
+	case c == '"':
+		start := s.pos
+		if err := s.skipString(); err != nil || !s.decode {
+			return nil, err
+		}
+		return unquote(s.data[start:s.pos])
+	case c == '{':
+		s.pos++
+		var m map[string]interface{}
+		if s.decode {
+			m = map[string]interface{}{}
+		}
+		s.skipSpace()
+		if s.consume('}') {
+			return m, nil
+		}
+		for {
+			s.skipSpace()
+			start := s.pos
+			if err := s.skipString(); err != nil {
+				return nil, err
+			}
+			keyEnd := s.pos
+			s.skipSpace()
+			if !s.consume(':') {
+				return nil, s.errorf("expected ':'")
+			}
+			s.skipSpace()
+			v, err := s.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if s.decode {
+				key, err := unquote(s.data[start:keyEnd])
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+			}
+			s.skipSpace()
+			if s.consume('}') {
+				return m, nil
+			}
+			if !s.consume(',') {
+				return nil, s.errorf("expected ',' or '}'")
+			}
+		}
+	case c == '[':
+		s.pos++
+		var a []interface{}
+		if s.decode {
+			a = []interface{}{}
+		}
+		s.skipSpace()
+		if s.consume(']') {
+			return a, nil
+		}
+		for {
+			s.skipSpace()
+			v, err := s.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if s.decode {
+				a = append(a, v)
+			}
+			s.skipSpace()
+			if s.consume(']') {
+				return a, nil
+			}
+			if !s.consume(',') {
+				return nil, s.errorf("expected ',' or ']'")
+			}
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := s.pos
+		if err := s.skipNumber(); err != nil || !s.decode {
+			return nil, err
+		}
+		num := string(s.data[start:s.pos])
+		if s.useNumber {
+			return json.Number(num), nil
+		}
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return nil, s.errorf("number %s out of range", num)
+		}
+		return f, nil
+	default:
+		for _, lit := range literals {
+			if bytes.HasPrefix(s.data[s.pos:], lit.text) {
+				s.pos += len(lit.text)
+				return lit.value, nil
+			}
+		}
+		return nil, s.errorf("unexpected character %q", c)
+	}
+}
+
+var literals = [...]struct {
+	text  []byte
+	value interface{}
+}{
+	{[]byte("true"), true},
+	{[]byte("false"), false},
+	{[]byte("null"), nil},
+}
+
+func (s *scanner) skipString() error {
+	if !s.consume('"') {
+		return s.errorf("expected a string")
+	}
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return nil
+		case c == '\\':
+			if s.pos+1 >= len(s.data) {
+				return s.errorf("unexpected end")
+			}
+			switch s.data[s.pos+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				s.pos += 2
+			case 'u':
+				if s.pos+6 > len(s.data) {
+					return s.errorf("unexpected end")
+				}
+				for _, h := range s.data[s.pos+2 : s.pos+6] {
+					if !isHex(h) {
+						return s.errorf("invalid escape")
+					}
+				}
+				s.pos += 6
+			default:
+				return s.errorf("invalid escape")
+			}
+		case c < 0x20:
+			return s.errorf("control character in string")
+		default:
+			s.pos++
+		}
+	}
+	return s.errorf("unterminated string")
+}
+
+func (s *scanner) skipNumber() error {
+	start := s.pos
+	s.consume('-')
+	switch {
+	case s.consume('0'):
+	case s.pos < len(s.data) && s.data[s.pos] >= '1' && s.data[s.pos] <= '9':
+		s.skipDigits()
+	default:
+		return s.errorf("invalid number")
+	}
+	if s.consume('.') {
+		if s.skipDigits() == 0 {
+			return s.errorf("invalid number")
+		}
+	}
+	if s.consume('e') || s.consume('E') {
+		if !s.consume('+') {
+			s.consume('-')
+		}
+		if s.skipDigits() == 0 {
+			return s.errorf("invalid number")
+		}
+	}
+	if s.pos == start {
+		return s.errorf("invalid number")
+	}
+	return nil
+}
+
+func (s *scanner) skipDigits() int {
+	start := s.pos
+	for s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '9' {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// unquote decodes a JSON string, without encoding/json unless it has escape
+// sequences or invalid UTF-8.
+func unquote(raw []byte) (string, error) {
+	inner := raw[1 : len(raw)-1]
+	if bytes.IndexByte(inner, '\\') < 0 && utf8.Valid(inner) {
+		return string(inner), nil
+	}
+	var s string
+	err := json.Unmarshal(raw, &s)
+	return s, err
+}
+
+// decodeRaw decodes a raw value like json.Unmarshal into an interface{},
+// with the numbers as json.Number if useNumber is set.
+func decodeRaw(raw json.RawMessage, useNumber bool) (interface{}, error) {
+	s := scanner{data: raw, decode: true, useNumber: useNumber}
+	s.skipSpace()
+	v, err := s.value(0)
+	if err != nil {
+		return nil, err
+	}
+	s.skipSpace()
+	if s.pos != len(s.data) {
+		return nil, s.errorf("unexpected data after the value")
+	}
+	return v, nil
+}
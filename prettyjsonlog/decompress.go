@@ -0,0 +1,57 @@
+package prettyjsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressors open the compressed inputs, detected by their magic bytes.
+var decompressors = []struct {
+	magic []byte
+	open  func(r io.Reader) (io.Reader, error)
+}{
+	{[]byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	}},
+	{[]byte("BZh"), func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	}},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}},
+}
+
+// decompress returns the decompressed content of r if it starts like a
+// gzip, bzip2 or zstd stream, r as is otherwise.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	for _, d := range decompressors {
+		if bytes.HasPrefix(head, d.magic) {
+			return d.open(br)
+		}
+	}
+	return br, nil
+}
+
+// decompressedFile reads the decompressed content of a file and closes both.
+type decompressedFile struct {
+	io.Reader
+	file io.Closer
+}
+
+func (d *decompressedFile) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.file.Close()
+}
@@ -0,0 +1,76 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// deduper collapses consecutive identical lines: the first one is shown and
+// the number of repetitions is shown when a different line comes.
+type deduper struct {
+	p *PrettyJsonLog
+
+	mu    sync.Mutex
+	key   string
+	first time.Time
+	count int
+}
+
+// check reports whether the line repeats the previous one. If it doesn't,
+// it returns the repetitions marker of the previous line, if any.
+func (d *deduper) check(source string, line *logLine) (bool, string) {
+	key := source + "\x00" + d.p.dedupeKey(line)
+	t, ok := line.parsedTime()
+	if !ok {
+		t = time.Now()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	window := d.p.config.DedupeWindow
+	if key == d.key && (window == 0 || t.Sub(d.first) <= window) {
+		d.count++
+		return true, ""
+	}
+	marker := d.marker()
+	d.key, d.first, d.count = key, t, 1
+	return false, marker
+}
+
+// flush returns the repetitions marker of the last line, if any, and forgets
+// it.
+func (d *deduper) flush() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	marker := d.marker()
+	d.key, d.count = "", 0
+	return marker
+}
+
+func (d *deduper) marker() string {
+	if d.count <= 1 || d.p.config.Output == "json" {
+		return ""
+	}
+	return d.p.paint(d.p.theme.Time, fmt.Sprintf("  ↑ ×%d", d.count)) + "\n"
+}
+
+// dedupeKey returns the fields of the line compared to find repeated lines:
+// the --dedupe-keys, or all the fields but the time.
+func (p *PrettyJsonLog) dedupeKey(line *logLine) string {
+	fields := map[string]json.RawMessage{}
+	if len(p.config.DedupeKeys) > 0 {
+		for _, k := range p.config.DedupeKeys {
+			fields[k], _ = line.rawField(k)
+		}
+	} else {
+		for k, v := range line.line {
+			fields[k] = v
+		}
+		if _, timeKey, _ := line.time(); timeKey != "" {
+			delete(fields, timeKey)
+		}
+	}
+	key, _ := json.Marshal(fields)
+	return string(key)
+}
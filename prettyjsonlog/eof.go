@@ -0,0 +1,50 @@
+package prettyjsonlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// eofPollInterval is the time between two reads of stdin at EOF with
+// --no-exit-on-eof.
+const eofPollInterval = 250 * time.Millisecond
+
+// eofWaiter reads r again after EOF until ctx is canceled, for the inputs
+// written again after their writer closed them, like a FIFO redirected to
+// stdin.
+type eofWaiter struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (w *eofWaiter) Read(b []byte) (int, error) {
+	for {
+		n, err := w.r.Read(b)
+		if err != io.EOF {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+		select {
+		case <-w.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(eofPollInterval):
+		}
+	}
+}
+
+// isFIFO tells if path is a named pipe.
+func isFIFO(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode()&os.ModeNamedPipe != 0
+}
+
+// openFIFO opens a named pipe for reading and writing: as it has a writer
+// itself, reading it waits for the next writer when one closes it instead of
+// returning EOF, and opening it doesn't wait for a writer.
+func openFIFO(path string) (io.Reader, error) {
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
@@ -0,0 +1,118 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// execQueueSize is the number of matching lines waiting for the --exec
+// command, the lines matching when it's full are skipped.
+const execQueueSize = 100
+
+// execHook runs the --exec command for each line shown matching the
+// --exec-on-match filter, one at a time in the order of the lines.
+type execHook struct {
+	filter  *lineFilter
+	command string
+
+	// mu is held while queuing a line, and by close to stop the queue.
+	mu      sync.Mutex
+	closed  bool
+	lines   chan execLine
+	done    chan struct{}
+	skipped sync.Once
+	failed  sync.Once
+}
+
+// execLine is a matching line, as compact JSON and the values of the
+// placeholders of the command.
+type execLine struct {
+	json         string
+	placeholders *strings.Replacer
+}
+
+func newExecHook(filter, command string) (*execHook, error) {
+	f, err := newLineFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	h := &execHook{filter: f, command: command, lines: make(chan execLine, execQueueSize), done: make(chan struct{})}
+	go h.run()
+	return h, nil
+}
+
+// check queues the line for the command if it matches.
+func (h *execHook) check(line *logLine) {
+	if !h.filter.match(line.decoded()) {
+		return
+	}
+	data, err := json.Marshal(line.line)
+	if err != nil {
+		return
+	}
+	level, _ := line.level()
+	msg, _ := line.message()
+	var t string
+	if parsed, ok := line.parsedTime(); ok {
+		t = parsed.Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	placeholders := strings.NewReplacer(
+		"{json}", shellQuote(string(data)),
+		"{level}", shellQuote(level),
+		"{msg}", shellQuote(msg),
+		"{time}", shellQuote(t),
+	)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.lines <- execLine{json: string(data), placeholders: placeholders}:
+	default:
+		h.skipped.Do(func() { log.Println("exec: the command is too slow, matching lines are skipped") })
+	}
+}
+
+// run runs the command for the queued lines, with the line on stdin and
+// the placeholders {json}, {level}, {msg} and {time} replaced by the values
+// of the line, quoted for the shell.
+func (h *execHook) run() {
+	defer close(h.done)
+	for l := range h.lines {
+		cmd := exec.Command("sh", "-c", l.placeholders.Replace(h.command))
+		cmd.Stdin = strings.NewReader(l.json + "\n")
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			h.failed.Do(func() { log.Printf("exec: %v", err) })
+		}
+	}
+}
+
+// close waits for the commands of the lines queued.
+func (h *execHook) close() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.lines)
+	}
+	h.mu.Unlock()
+	<-h.done
+}
+
+// shellQuote quotes s as a single word for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// closeExec waits for the --exec commands still running, if any.
+func (p *PrettyJsonLog) closeExec() {
+	if p.execHook != nil {
+		p.execHook.close()
+	}
+}
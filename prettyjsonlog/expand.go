@@ -0,0 +1,96 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// shouldExpand reports whether a field value is an object or array large or
+// deep enough to be shown as an indented block with --expand.
+func (p *PrettyJsonLog) shouldExpand(vi interface{}) bool {
+	if !p.config.Expand {
+		return false
+	}
+	switch vi.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return false
+	}
+	leaves, depth := jsonSize(vi)
+	return leaves > p.config.ExpandSize || depth > p.config.ExpandDepth
+}
+
+// jsonSize returns the number of scalar values in a decoded JSON value and
+// its nesting depth.
+func jsonSize(vi interface{}) (int, int) {
+	var children []interface{}
+	switch vi := vi.(type) {
+	case map[string]interface{}:
+		for _, v := range vi {
+			children = append(children, v)
+		}
+	case []interface{}:
+		children = vi
+	default:
+		return 1, 0
+	}
+	leaves, depth := 0, 0
+	for _, c := range children {
+		l, d := jsonSize(c)
+		leaves += l
+		depth = max(depth, d)
+	}
+	return leaves, depth + 1
+}
+
+// prettyJSON renders a decoded JSON value at path as indented multi-line
+// JSON.
+func (l *logLine) prettyJSON(path string, vi interface{}, indent string) string {
+	const step = "  "
+	t, paint := l.p.theme, l.p.paint
+	switch vi := vi.(type) {
+	case map[string]interface{}:
+		if len(vi) == 0 {
+			return paint(t.Object, "{}")
+		}
+		var sb strings.Builder
+		sb.WriteString(paint(t.Object, "{") + "\n")
+		keys := l.objectKeys(path, vi)
+		for i, k := range keys {
+			sb.WriteString(indent + step + paint(t.FieldKey, strconv.Quote(k)) + paint(t.Object, ": ") + l.prettyJSON(path+"."+k, vi[k], indent+step))
+			if i < len(keys)-1 {
+				sb.WriteString(paint(t.Object, ","))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(indent + paint(t.Object, "}"))
+		return sb.String()
+	case []interface{}:
+		if len(vi) == 0 {
+			return paint(t.Array, "[]")
+		}
+		var sb strings.Builder
+		sb.WriteString(paint(t.Array, "[") + "\n")
+		for i, v := range vi {
+			sb.WriteString(indent + step + l.prettyJSON(path, v, indent+step))
+			if i < len(vi)-1 {
+				sb.WriteString(paint(t.Array, ","))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(indent + paint(t.Array, "]"))
+		return sb.String()
+	case string:
+		b, _ := json.Marshal(vi)
+		return paint(t.String, string(b))
+	case json.Number:
+		return paint(t.Number, vi.String())
+	case bool:
+		return paint(t.Bool, strconv.FormatBool(vi))
+	case nil:
+		return paint(t.Null, "null")
+	}
+	return paint(t.Other, fmt.Sprint(vi))
+}
@@ -0,0 +1,98 @@
+package prettyjsonlog
+
+import "encoding/json"
+
+// resolvePath returns the keys of the nested objects leading to a field given
+// by a dot separated path, and its raw value. The keys may hold dots too,
+// like the ECS style flat keys: "log.origin.file" is found in
+// {"log.origin.file": ...}, {"log": {"origin": {"file": ...}}} or
+// {"log": {"origin.file": ...}}, the longest keys first.
+func resolvePath(fields map[string]json.RawMessage, key string) ([]string, json.RawMessage, bool) {
+	if raw, ok := fields[key]; ok {
+		return []string{key}, raw, true
+	}
+	for i := len(key) - 1; i > 0; i-- {
+		if key[i] != '.' {
+			continue
+		}
+		raw, ok := fields[key[:i]]
+		if !ok {
+			continue
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &m); err != nil {
+			continue
+		}
+		if path, value, ok := resolvePath(m, key[i+1:]); ok {
+			return append([]string{key[:i]}, path...), value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// rawField returns the raw value of a field given by its key or the dot
+// separated path of a nested field.
+func (l *logLine) rawField(key string) (json.RawMessage, bool) {
+	_, raw, ok := resolvePath(l.line, key)
+	return raw, ok
+}
+
+// deleteField removes a field given like in rawField. The objects left
+// empty are removed too.
+func (l *logLine) deleteField(key string) {
+	path, _, ok := resolvePath(l.line, key)
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		delete(l.line, key)
+		return
+	}
+	if _, rest, ok := extractPath(l.line[path[0]], path[1:]); ok {
+		if rest == nil {
+			delete(l.line, path[0])
+		} else {
+			l.line[path[0]] = rest
+		}
+	}
+}
+
+// lookupPath returns the decoded value at a key or dot separated path,
+// resolved like in resolvePath.
+func lookupPath(fields map[string]interface{}, path string) (interface{}, bool) {
+	if v, ok := fields[path]; ok {
+		return v, true
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' {
+			continue
+		}
+		if m, ok := fields[path[:i]].(map[string]interface{}); ok {
+			if v, ok := lookupPath(m, path[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// deletePath removes the decoded value at a key or dot separated path,
+// resolved like in resolvePath. The objects left empty are removed too.
+func deletePath(fields map[string]interface{}, path string) bool {
+	if _, ok := fields[path]; ok {
+		delete(fields, path)
+		return true
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' {
+			continue
+		}
+		if m, ok := fields[path[:i]].(map[string]interface{}); ok && deletePath(m, path[i+1:]) {
+			if len(m) == 0 {
+				delete(fields, path[:i])
+			}
+			return true
+		}
+	}
+	return false
+}
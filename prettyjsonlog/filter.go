@@ -0,0 +1,74 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// lineFilter evaluates a jq expression (eg. `.status >= 500 and .service ==
+// "api"`) against the decoded JSON of a line.
+type lineFilter struct {
+	code *gojq.Code
+}
+
+func newLineFilter(expr string) (*lineFilter, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return &lineFilter{code: code}, nil
+}
+
+// match reports whether the first result of the expression is truthy, the
+// same way `jq 'select(expr)'` would. Evaluation errors don't match.
+func (f *lineFilter) match(v map[string]interface{}) bool {
+	iter := f.code.Run(v)
+	result, ok := iter.Next()
+	if !ok {
+		return false
+	}
+	if _, ok := result.(error); ok {
+		return false
+	}
+	return result != nil && result != false
+}
+
+// grepMatch reports whether text matches the --grep pattern and doesn't match
+// the --grep-v pattern.
+func (p *PrettyJsonLog) grepMatch(text string) bool {
+	if p.grep != nil && !p.grep.MatchString(text) {
+		return false
+	}
+	if p.grepV != nil && p.grepV.MatchString(text) {
+		return false
+	}
+	return true
+}
+
+// decoded returns the fields of the line decoded as plain Go values.
+func (l *logLine) decoded() map[string]interface{} {
+	return l.decode(false)
+}
+
+// decodedNumbers returns the fields like decoded, with the numbers as
+// json.Number to be printed as written.
+func (l *logLine) decodedNumbers() map[string]interface{} {
+	return l.decode(true)
+}
+
+func (l *logLine) decode(useNumber bool) map[string]interface{} {
+	res := make(map[string]interface{}, len(l.line))
+	for k, raw := range l.line {
+		v, err := decodeRaw(raw, useNumber)
+		if err != nil {
+			continue
+		}
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package prettyjsonlog
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const followPollInterval = 250 * time.Millisecond
+
+// followReader reads a file like `tail -F`: when the end of the file is
+// reached it waits for more data instead of returning io.EOF, and reopens the
+// file when it gets truncated or rotated (renamed and recreated). Read only
+// returns io.EOF after Close is called.
+type followReader struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	offset int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newFollowReader(path string) (*followReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &followReader{path: path, file: f, stopCh: make(chan struct{})}, nil
+}
+
+func (r *followReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		n, err := r.file.Read(b)
+		r.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		r.reopenIfChanged()
+
+		select {
+		case <-r.stopCh:
+			return 0, io.EOF
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// reopenIfChanged is called at the end of the current file. It starts over
+// from the beginning if the file was truncated, and switches to the new file
+// if the path now points to a different file.
+func (r *followReader) reopenIfChanged() {
+	st, err := os.Stat(r.path)
+	if err != nil {
+		// the file was removed, wait for it to be recreated
+		return
+	}
+	cur, err := r.file.Stat()
+	if err != nil {
+		return
+	}
+	if !os.SameFile(st, cur) {
+		f, err := os.Open(r.path)
+		if err != nil {
+			return
+		}
+		log.Printf("%s: file rotated, following new file", r.path)
+		r.file.Close()
+		r.file = f
+		r.offset = 0
+		return
+	}
+	if st.Size() < r.offset {
+		log.Printf("%s: file truncated", r.path)
+		if _, err := r.file.Seek(0, io.SeekStart); err == nil {
+			r.offset = 0
+		}
+	}
+}
+
+func (r *followReader) Close() error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -0,0 +1,135 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-isatty"
+)
+
+// footerInterval is the time between two updates of the --footer rate.
+const footerInterval = time.Second
+
+// footer is the line of counters kept below the lines written to a terminal
+// with --footer: the lines read per second, per level and not parsed. It's
+// erased before the lines are written and drawn again once they're flushed.
+// Its methods do nothing on a nil *footer.
+type footer struct {
+	p *PrettyJsonLog
+	w io.Writer
+	// shown is set while the footer is on the last line of the terminal.
+	shown bool
+
+	// lines is the number of lines read at the last update, at time at.
+	lines int
+	at    time.Time
+	rate  float64
+}
+
+// newFooter returns the footer of the --footer option, nil if disabled or
+// if out isn't a terminal. The lines are counted by the stats.
+func (p *PrettyJsonLog) newFooter(w io.Writer) *footer {
+	if !p.config.Footer {
+		return nil
+	}
+	if f, ok := p.out.(*os.File); !ok || !isatty.IsTerminal(f.Fd()) {
+		return nil
+	}
+	if p.stats == nil {
+		p.stats = newStats()
+	}
+	return &footer{p: p, w: w, at: time.Now()}
+}
+
+func (f *footer) Write(b []byte) (int, error) {
+	if err := f.erase(); err != nil {
+		return 0, err
+	}
+	return f.w.Write(b)
+}
+
+// erase clears the footer from the terminal, if shown.
+func (f *footer) erase() error {
+	if f == nil || !f.shown {
+		return nil
+	}
+	f.shown = false
+	_, err := io.WriteString(f.w, "\r\x1b[K")
+	return err
+}
+
+// update computes the rate of the lines read since the last update.
+func (f *footer) update() {
+	if f == nil {
+		return
+	}
+	s := f.p.stats
+	s.mu.Lock()
+	lines := s.lines
+	s.mu.Unlock()
+	now := time.Now()
+	if elapsed := now.Sub(f.at).Seconds(); elapsed > 0 {
+		f.rate = float64(lines-f.lines) / elapsed
+	}
+	f.lines, f.at = lines, now
+}
+
+// draw writes the footer again with the current counters, without a
+// trailing newline for the cursor to stay on it.
+func (f *footer) draw() {
+	if f == nil {
+		return
+	}
+	p := f.p
+	s := p.stats
+	s.mu.Lock()
+	levels := make([]string, 0, len(s.levels))
+	for level := range s.levels {
+		levels = append(levels, level)
+	}
+	p.sortLevels(levels)
+	parts := []string{p.paint(p.theme.Time, fmt.Sprintf("%.0f lines/s", f.rate))}
+	for _, level := range levels {
+		name := level
+		if name == "" {
+			name = "NO LEVEL"
+		}
+		c, _ := p.theme.Level(level)
+		parts = append(parts, p.paint(c, name)+" "+fmt.Sprint(s.levels[level]))
+	}
+	parts = append(parts, p.paint(p.theme.NonJSON, "not parsed")+" "+fmt.Sprint(s.nonJSON))
+	if s.dropped > 0 {
+		parts = append(parts, p.paint(p.theme.NonJSON, "dropped")+" "+fmt.Sprint(s.dropped))
+	}
+	s.mu.Unlock()
+
+	text := strings.Join(parts, "  ")
+	// The footer must not wrap, or the erased line would be the last one of
+	// it only.
+	if width := p.termWidth(); width > 1 {
+		text = ansi.Truncate(text, width-1, "…")
+	}
+	f.erase()
+	io.WriteString(f.w, text)
+	f.shown = true
+}
+
+// sortLevels sorts normalized levels by severity, the unknown ones last.
+func (p *PrettyJsonLog) sortLevels(levels []string) {
+	sort.Slice(levels, func(i, j int) bool {
+		si, iok := p.levelSeverity[levels[i]]
+		sj, jok := p.levelSeverity[levels[j]]
+		if iok != jok {
+			return iok
+		}
+		if si != sj {
+			return si < sj
+		}
+		return levels[i] < levels[j]
+	})
+}
@@ -0,0 +1,50 @@
+package prettyjsonlog
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// gapDetector marks the quiet periods longer than the --gap threshold with
+// a separator. The time of the lines is compared, or the time they're read
+// at for the lines without a time.
+type gapDetector struct {
+	p *PrettyJsonLog
+
+	mu      sync.Mutex
+	prev    time.Time
+	arrival time.Time
+}
+
+// marker returns the separator shown before a line if the time since the
+// previous line is above the threshold.
+func (g *gapDetector) marker(t time.Time, hasTime bool) string {
+	now := time.Now()
+	g.mu.Lock()
+	prev := g.arrival
+	if hasTime {
+		prev = g.prev
+		g.prev = t
+	} else {
+		t = now
+	}
+	g.arrival = now
+	g.mu.Unlock()
+
+	if prev.IsZero() || g.p.config.Output == "json" {
+		return ""
+	}
+	d := t.Sub(prev)
+	if d <= g.p.config.Gap {
+		return ""
+	}
+	// The gaps are rounded to the second, or the millisecond below it, like
+	// 42s, 2m5s or 750ms.
+	unit := time.Second
+	if d < time.Second {
+		unit = time.Millisecond
+	}
+	bar := strings.Repeat("―", 3)
+	return g.p.paint(g.p.theme.Time, bar+" "+d.Round(unit).String()+" silence "+bar) + "\n"
+}
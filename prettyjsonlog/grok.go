@@ -0,0 +1,183 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// grokPatterns are the patterns usable as %{NAME} in the grok patterns, a
+// subset of the ones of Logstash.
+var grokPatterns = map[string]string{
+	"USERNAME":     `[a-zA-Z0-9._-]+`,
+	"USER":         `%{USERNAME}`,
+	"INT":          `[+-]?[0-9]+`,
+	"BASE10NUM":    `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"NUMBER":       `%{BASE10NUM}`,
+	"BASE16NUM":    `(?:0[xX])?[0-9a-fA-F]+`,
+	"POSINT":       `\b[1-9][0-9]*\b`,
+	"NONNEGINT":    `\b[0-9]+\b`,
+	"WORD":         `\b\w+\b`,
+	"NOTSPACE":     `\S+`,
+	"SPACE":        `\s*`,
+	"DATA":         `.*?`,
+	"GREEDYDATA":   `.*`,
+	"QUOTEDSTRING": `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"QS":           `%{QUOTEDSTRING}`,
+	"UUID":         `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+
+	"IPV4":           `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":           `(?:[0-9A-Fa-f]{0,4}:){2,7}(?:%{IPV4}|[0-9A-Fa-f]{0,4})(?:%\w+)?`,
+	"IP":             `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":       `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?`,
+	"IPORHOST":       `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":       `%{IPORHOST}:%{POSINT}`,
+	"EMAILLOCALPART": "[a-zA-Z0-9!#$%&'*+\\-/=?^_`{|}~]+(?:\\.[a-zA-Z0-9!#$%&'*+\\-/=?^_`{|}~]+)*",
+	"EMAILADDRESS":   `%{EMAILLOCALPART}@%{HOSTNAME}`,
+	"HTTPDUSER":      `%{EMAILADDRESS}|%{USER}`,
+
+	"UNIXPATH":     `(?:/[^/\s]*)+`,
+	"WINPATH":      `(?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+`,
+	"PATH":         `%{UNIXPATH}|%{WINPATH}`,
+	"URIPROTO":     `[A-Za-z][A-Za-z0-9+\-.]*`,
+	"URIHOST":      `%{IPORHOST}(?::%{POSINT})?`,
+	"URIPATH":      `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":     `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM": `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":          `%{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?(?:%{URIHOST})?(?:%{URIPATHPARAM})?`,
+
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]un(?:e)?|[Jj]ul(?:y)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `0[1-9]|[12][0-9]|3[01]|[1-9]`,
+	"DAY":               `Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?`,
+	"YEAR":              `(?:\d\d){1,2}`,
+	"HOUR":              `2[0123]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"DATE_US":           `%{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}`,
+	"DATE_EU":           `%{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}`,
+	"DATE":              `%{DATE_US}|%{DATE_EU}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+
+	"LOGLEVEL":  `[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo?(?:rmation)?|INFO?(?:RMATION)?|[Ww]arn?(?:ing)?|WARN?(?:ING)?|[Ee]rr?(?:or)?|ERR?(?:OR)?|[Cc]rit?(?:ical)?|CRIT?(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|[Ee]merg(?:ency)?|EMERG(?:ENCY)?`,
+	"JAVACLASS": `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+
+	"COMMONAPACHELOG":   `%{IPORHOST:clientip} %{HTTPDUSER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response:int} (?:%{NUMBER:bytes:int}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}`,
+}
+
+// builtinGrokParsers are the parsers of the common log formats, with the
+// names of the fields shown as the time, level and message, and of the
+// fields colored as HTTP fields.
+var builtinGrokParsers = map[string]string{
+	// The combined log format of nginx and Apache, the common one if the
+	// referer and user agent are missing.
+	"access-log": `^%{IPORHOST:remote_addr} (?:-|%{HTTPDUSER:ident}) (?:-|%{USER:user}) \[%{HTTPDATE:time}\] "(?:(?P<msg>%{WORD:method} %{NOTSPACE:path})(?: HTTP/%{NUMBER:http_version})?|(?P<request>[^"]*))" %{INT:status:int} (?:%{INT:bytes:int}|-)(?: "(?:-|%{DATA:referer})" "(?:-|%{DATA:user_agent})")?`,
+	// The default layout of logback and log4j: time [thread] level logger -
+	// message.
+	"logback": `^%{TIMESTAMP_ISO8601:time} \[%{DATA:thread}\] %{LOGLEVEL:level}\s+%{NOTSPACE:logger} - %{GREEDYDATA:msg}`,
+	// The default layout of Spring Boot: time level pid --- [app] [thread]
+	// logger : message.
+	"spring-boot": `^%{TIMESTAMP_ISO8601:time}\s+%{LOGLEVEL:level} %{POSINT:pid:int} --- (?:\[%{DATA:app}\] )?\[\s*%{DATA:thread}\] %{NOTSPACE:logger}\s*: %{GREEDYDATA:msg}`,
+}
+
+func init() {
+	for name, pattern := range builtinGrokParsers {
+		parser, err := newGrokParser(pattern, nil)
+		if err != nil {
+			panic(fmt.Sprintf("grok parser %s: %v", name, err))
+		}
+		RegisterParser(name, parser)
+	}
+}
+
+// grokRefRe matches a reference to a grok pattern: %{NAME}, %{NAME:field}
+// or %{NAME:field:type} where type is int or float.
+var grokRefRe = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(int|float))?\}`)
+
+// grokParser parses the lines matching a grok pattern, a regex where
+// %{NAME:field} matches the pattern NAME and captures it as the field. The
+// named groups of the regex, like (?P<field>...), are captured too.
+type grokParser struct {
+	re *regexp.Regexp
+	// fields and types are the field and type of the groups of the
+	// %{NAME:field} references, by group name.
+	fields map[string]string
+	types  map[string]string
+}
+
+// newGrokParser compiles a grok pattern, with the patterns defs added to
+// the built-in ones.
+func newGrokParser(pattern string, defs map[string]string) (*grokParser, error) {
+	g := &grokParser{fields: map[string]string{}, types: map[string]string{}}
+	var err error
+	var expand func(pattern string, depth int) string
+	expand = func(pattern string, depth int) string {
+		return grokRefRe.ReplaceAllStringFunc(pattern, func(ref string) string {
+			m := grokRefRe.FindStringSubmatch(ref)
+			def, ok := defs[m[1]]
+			if !ok {
+				def, ok = grokPatterns[m[1]]
+			}
+			if !ok {
+				err = fmt.Errorf("unknown grok pattern %q", m[1])
+				return ""
+			}
+			if depth > 20 {
+				err = fmt.Errorf("grok pattern %q references itself", m[1])
+				return ""
+			}
+			def = expand(def, depth+1)
+			if m[2] == "" {
+				return "(?:" + def + ")"
+			}
+			name := fmt.Sprintf("grok%d", len(g.fields))
+			g.fields[name], g.types[name] = m[2], m[3]
+			return "(?P<" + name + ">" + def + ")"
+		})
+	}
+	expanded := expand(pattern, 0)
+	if err != nil {
+		return nil, err
+	}
+	if g.re, err = regexp.Compile(expanded); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *grokParser) Parse(text string) (map[string]interface{}, bool) {
+	m := g.re.FindStringSubmatchIndex(text)
+	if m == nil {
+		return nil, false
+	}
+	fields := map[string]interface{}{}
+	for i, name := range g.re.SubexpNames() {
+		if name == "" || m[2*i] < 0 {
+			continue
+		}
+		s := text[m[2*i]:m[2*i+1]]
+		field, ok := g.fields[name]
+		if !ok {
+			field = name
+		}
+		switch g.types[name] {
+		case "int":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				fields[field] = n
+				continue
+			}
+		case "float":
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				fields[field] = f
+				continue
+			}
+		}
+		fields[field] = s
+	}
+	return fields, true
+}
@@ -0,0 +1,59 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// maxGroups bounds the number of IDs remembered by --group-by, the oldest
+// ones are forgotten first.
+const maxGroups = 10000
+
+// grouper marks the lines sharing the value of the --group-by field: the
+// first line of a group is marked with ▶ and the next ones are indented
+// under it, in a color derived from the value.
+type grouper struct {
+	p *PrettyJsonLog
+
+	mu    sync.Mutex
+	seen  map[string]bool
+	order []string
+}
+
+// mark returns the prefix of the line and colors its group field.
+func (g *grouper) mark(line *logLine) string {
+	v, ok := lookupPath(line.decoded(), g.p.config.GroupBy)
+	if !ok || v == nil || v == "" {
+		return ""
+	}
+	id := fmt.Sprint(v)
+	c := g.p.stableColor(id)
+	if line.highlights == nil {
+		line.highlights = map[string]*color.Color{}
+	}
+	line.highlights[g.p.config.GroupBy] = c
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.seen[id] {
+		return g.p.paint(c, "  └ ")
+	}
+	g.seen[id] = true
+	g.order = append(g.order, id)
+	if len(g.order) > maxGroups {
+		delete(g.seen, g.order[0])
+		g.order = g.order[1:]
+	}
+	return g.p.paint(c, "▶ ")
+}
+
+// stableColor returns a color of the source palette derived from s, the same
+// for the same value.
+func (p *PrettyJsonLog) stableColor(s string) *color.Color {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return p.theme.SourceColor(int(h.Sum32() % 1024))
+}
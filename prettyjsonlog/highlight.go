@@ -0,0 +1,315 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+)
+
+// HighlightRule colors the values of some fields when a condition on the
+// fields of the line holds, eg. `status >= 500` or `level == debug &&
+// component == "db"`.
+type HighlightRule struct {
+	// When is the condition: comparisons of a field (by key or dot separated
+	// path) with a value using ==, !=, >, >=, <, <= or =~ (regex match),
+	// joined with && and ||. && binds tighter than ||.
+	When string `yaml:"when"`
+	// Color is the color spec of the highlighted values.
+	Color string `yaml:"color"`
+	// Fields are the fields highlighted, "*" for the message and all the
+	// fields. They default to the fields of the condition.
+	Fields []string `yaml:"fields"`
+}
+
+var conditionRe = regexp.MustCompile(`^\s*([\w.@-]+)\s*(==|!=|>=|<=|=~|>|<)\s*([^=!<>~\s].*?)\s*$`)
+
+// condition compares a field with a value.
+type condition struct {
+	path  string
+	op    string
+	value interface{}
+	re    *regexp.Regexp
+}
+
+// highlightRule is a parsed HighlightRule. when is a list of alternatives,
+// each one a list of conditions that must all hold.
+type highlightRule struct {
+	when   [][]condition
+	color  *color.Color
+	fields []string
+}
+
+func newHighlightRule(rule HighlightRule) (*highlightRule, error) {
+	c, err := parseColor(rule.Color)
+	if err != nil {
+		return nil, fmt.Errorf("highlight rule %q: %w", rule.When, err)
+	}
+	r := &highlightRule{color: c, fields: rule.Fields}
+	if r.when, err = parseWhen(rule.When); err != nil {
+		return nil, fmt.Errorf("highlight rule %q: %w", rule.When, err)
+	}
+	if len(rule.Fields) == 0 {
+		for _, all := range r.when {
+			for _, cond := range all {
+				r.fields = append(r.fields, cond.path)
+			}
+		}
+	}
+	return r, nil
+}
+
+// parseWhen parses conditions joined with && and ||, as a list of
+// alternatives, each one a list of conditions that must all hold.
+func parseWhen(when string) ([][]condition, error) {
+	var alts [][]condition
+	for _, alt := range splitUnquoted(when, "||") {
+		var all []condition
+		for _, s := range splitUnquoted(alt, "&&") {
+			cond, err := parseCondition(s)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, cond)
+		}
+		alts = append(alts, all)
+	}
+	return alts, nil
+}
+
+// splitUnquoted splits s around sep, outside of the quoted strings, so that
+// a value like "a||b" isn't split.
+func splitUnquoted(s, sep string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// matchWhen reports whether one of the alternatives of parseWhen holds for
+// the decoded fields of a line.
+func matchWhen(when [][]condition, fields map[string]interface{}) bool {
+	for _, all := range when {
+		matched := true
+		for _, cond := range all {
+			if !cond.match(fields) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCondition(s string) (condition, error) {
+	m := conditionRe.FindStringSubmatch(s)
+	if m == nil {
+		return condition{}, fmt.Errorf("invalid condition %q", strings.TrimSpace(s))
+	}
+	cond := condition{path: m[1], op: m[2], value: parseLiteral(m[3])}
+	if cond.op == "=~" {
+		var err error
+		if cond.re, err = regexp.Compile(fmt.Sprint(cond.value)); err != nil {
+			return condition{}, fmt.Errorf("invalid condition %q: %w", strings.TrimSpace(s), err)
+		}
+	}
+	return cond, nil
+}
+
+// parseLiteral parses the value of a condition: a quoted string, a number,
+// true, false, null or else an unquoted string.
+func parseLiteral(s string) interface{} {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	return s
+}
+
+// match reports whether the condition holds for the decoded fields of a line.
+func (c condition) match(fields map[string]interface{}) bool {
+	v, ok := lookupPath(fields, c.path)
+	if !ok {
+		return c.op == "!="
+	}
+	if c.re != nil {
+		return c.re.MatchString(fmt.Sprint(v))
+	}
+	if a, ok := v.(float64); ok {
+		if b, ok := c.value.(float64); ok {
+			switch c.op {
+			case "==":
+				return a == b
+			case "!=":
+				return a != b
+			case ">":
+				return a > b
+			case ">=":
+				return a >= b
+			case "<":
+				return a < b
+			case "<=":
+				return a <= b
+			}
+		}
+	}
+	switch c.op {
+	case "==":
+		return equalValues(v, c.value)
+	case "!=":
+		return !equalValues(v, c.value)
+	}
+	return false
+}
+
+// equalValues compares a field with a value, strings case insensitively.
+func equalValues(v, value interface{}) bool {
+	if s, ok := v.(string); ok {
+		if t, ok := value.(string); ok {
+			return strings.EqualFold(s, t)
+		}
+		if f, ok := value.(float64); ok {
+			return s == strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return v == value
+}
+
+// applyHighlightRules sets the colors of the fields of the line highlighted
+// by the rules. The level is matched by its normalized name.
+func (l *logLine) applyHighlightRules() {
+	fields := l.decoded()
+	if level, key := l.level(); key != "" {
+		fields[key] = level
+	}
+	for _, r := range l.p.highlightRules {
+		if !matchWhen(r.when, fields) {
+			continue
+		}
+		if l.highlights == nil {
+			l.highlights = map[string]*color.Color{}
+		}
+		for _, f := range r.fields {
+			l.highlights[f] = r.color
+		}
+	}
+}
+
+// highlight returns the color of a highlighted field, by path.
+func (l *logLine) highlight(path string) (*color.Color, bool) {
+	if c, ok := l.highlights[path]; ok {
+		return c, true
+	}
+	c, ok := l.highlights["*"]
+	return c, ok
+}
+
+// highlightValue recolors a rendered value of the field at path if it's
+// highlighted.
+func (l *logLine) highlightValue(path, s string) string {
+	if c, ok := l.highlight(path); ok {
+		return l.p.paint(c, ansi.Strip(s))
+	}
+	return s
+}
+
+// highlightPattern is a --highlight pattern with its color.
+type highlightPattern struct {
+	re    *regexp.Regexp
+	color *color.Color
+}
+
+// parseHighlight parses a --highlight pattern, a regex optionally followed by
+// @ and a color spec (eg. "timeout|refused@hi-white bg-red"). Without a valid
+// color the whole pattern is the regex, colored with the highlight color of
+// the theme (set later, nil here).
+func parseHighlight(s string) (highlightPattern, error) {
+	pattern := s
+	var c *color.Color
+	if i := strings.LastIndex(s, "@"); i >= 0 && strings.TrimSpace(s[i+1:]) != "" {
+		if pc, err := parseColor(s[i+1:]); err == nil {
+			pattern, c = s[:i], pc
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return highlightPattern{}, fmt.Errorf("invalid highlight pattern: %w", err)
+	}
+	return highlightPattern{re: re, color: c}, nil
+}
+
+// highlightMatches colors the parts of s matching the --highlight patterns
+// and the rest with base (left as is if nil). The first pattern wins where
+// matches overlap.
+func (p *PrettyJsonLog) highlightMatches(s string, base *color.Color) string {
+	sprint := func(c *color.Color, s string) string {
+		if c == nil || s == "" {
+			return s
+		}
+		return p.paint(c, s)
+	}
+	if len(p.highlights) == 0 {
+		return sprint(base, s)
+	}
+	colors := make([]*color.Color, len(s))
+	matched := false
+	for _, h := range p.highlights {
+		for _, loc := range h.re.FindAllStringIndex(s, -1) {
+			for i := loc[0]; i < loc[1]; i++ {
+				if colors[i] == nil {
+					colors[i] = h.color
+					matched = true
+				}
+			}
+		}
+	}
+	if !matched {
+		return sprint(base, s)
+	}
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(s); i++ {
+		if i < len(s) && colors[i] == colors[start] {
+			continue
+		}
+		c := colors[start]
+		if c == nil {
+			c = base
+		}
+		b.WriteString(sprint(c, s[start:i]))
+		start = i
+	}
+	return b.String()
+}
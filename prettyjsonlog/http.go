@@ -0,0 +1,77 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// httpFields maps the keys of the common fields of HTTP access logs to the
+// kind of value they hold.
+var httpFields = map[string]string{
+	"status":      "status",
+	"status_code": "status",
+	"statusCode":  "status",
+	"http_status": "status",
+
+	"method":         "method",
+	"http_method":    "method",
+	"httpMethod":     "method",
+	"request_method": "method",
+
+	"path":        "path",
+	"url":         "path",
+	"uri":         "path",
+	"request_uri": "path",
+	"requestUrl":  "path",
+
+	"remote_addr": "addr",
+	"remoteAddr":  "addr",
+	"remote_ip":   "addr",
+	"client_ip":   "addr",
+	"clientIp":    "addr",
+}
+
+// httpColor returns the color of a value of an HTTP access log field: status
+// codes by class, methods by name, paths and client addresses. It returns nil
+// for other fields or unexpected values.
+func (p *PrettyJsonLog) httpColor(path string, vi interface{}) *color.Color {
+	if p.config.NoHTTPColors {
+		return nil
+	}
+	var s string
+	switch v := vi.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return nil
+	}
+	switch httpFields[path[strings.LastIndex(path, ".")+1:]] {
+	case "status":
+		code, err := strconv.Atoi(s)
+		if err != nil || code < 100 || code > 599 {
+			return nil
+		}
+		return p.theme.HTTP["http-"+strconv.Itoa(code/100)+"xx"]
+	case "method":
+		if c, ok := p.theme.HTTP["http-"+strings.ToLower(s)]; ok {
+			return c
+		}
+		if s == strings.ToUpper(s) {
+			return p.theme.HTTP["http-method"]
+		}
+	case "path":
+		if _, ok := vi.(string); ok {
+			return p.theme.HTTP["http-path"]
+		}
+	case "addr":
+		if _, ok := vi.(string); ok {
+			return p.theme.HTTP["http-addr"]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// defaultEditorURL opens the caller files in VS Code.
+const defaultEditorURL = "vscode://file{path}:{line}"
+
+// defaultTraceFields are the fields linked to --trace-url when --trace-fields
+// is not set.
+var defaultTraceFields = []string{"trace_id", "traceId", "traceID", "trace.id", "dd.trace_id", "logging.googleapis.com/trace"}
+
+// hyperlinks reports whether the caller and trace ID fields are rendered as
+// OSC 8 hyperlinks, only when writing colors to a terminal.
+func (p *PrettyJsonLog) hyperlinks() bool {
+	return p.config.Hyperlinks && p.useColor
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink to url.
+func hyperlink(url, text string) string {
+	return ansi.SetHyperlink(url) + text + ansi.ResetHyperlink()
+}
+
+// editorURL returns the link to a source location from the --editor-url
+// template, replacing {path}, {line} and {col}. Relative paths are resolved
+// from the working directory.
+func (p *PrettyJsonLog) editorURL(file, line, col string) string {
+	if !filepath.IsAbs(file) {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+	}
+	if line == "" {
+		line = "1"
+	}
+	if col == "" {
+		col = "1"
+	}
+	return strings.NewReplacer("{path}", filepath.ToSlash(file), "{line}", line, "{col}", col).Replace(p.config.EditorURL)
+}
+
+// isTraceField reports whether the value at path is a trace ID, by path or
+// key.
+func (p *PrettyJsonLog) isTraceField(path string) bool {
+	key := path[strings.LastIndex(path, ".")+1:]
+	for _, f := range p.config.TraceFields {
+		if f == path || f == key {
+			return true
+		}
+	}
+	return false
+}
+
+// linkTrace links a rendered trace ID to the --trace-url template, replacing
+// {id}.
+func (p *PrettyJsonLog) linkTrace(path string, vi interface{}, rendered string) string {
+	if !p.hyperlinks() || p.config.TraceURL == "" || !p.isTraceField(path) {
+		return rendered
+	}
+	var id string
+	switch v := vi.(type) {
+	case string:
+		if isMultiline(v) {
+			return rendered
+		}
+		id = v
+	case json.Number:
+		id = v.String()
+	default:
+		return rendered
+	}
+	return hyperlink(strings.ReplaceAll(p.config.TraceURL, "{id}", url.PathEscape(id)), rendered)
+}
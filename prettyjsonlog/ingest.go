@@ -0,0 +1,102 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxIngestSize bounds the size of the bodies received by the HTTP listener.
+const maxIngestSize = 64 << 20
+
+// ingestHandler receives the logs POSTed to the HTTP listener, gzipped or
+// not: Loki push requests in JSON on /loki/api/v1/push, OTLP logs on
+// /v1/logs, and NDJSON bodies on the other paths.
+func (p *PrettyJsonLog) ingestHandler(sources chan<- Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		var in io.Reader = http.MaxBytesReader(w, r.Body, maxIngestSize)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(in)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			in = io.LimitReader(gz, maxIngestSize)
+		}
+		body, err := io.ReadAll(in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.URL.Path == "/v1/logs" {
+			ingestOTLP(w, ct, body, sources)
+			return
+		}
+		if r.URL.Path != "/loki/api/v1/push" {
+			sources <- Source{Reader: bytes.NewReader(body)}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if ct != "application/json" {
+			http.Error(w, "only JSON push requests are supported", http.StatusUnsupportedMediaType)
+			return
+		}
+		var push struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][]string        `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.Unmarshal(body, &push); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, stream := range push.Streams {
+			var lines strings.Builder
+			for _, v := range stream.Values {
+				if len(v) >= 2 {
+					lines.WriteString(v[1] + "\n")
+				}
+			}
+			sources <- Source{Name: LokiLabel(stream.Stream), Reader: strings.NewReader(lines.String())}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// LokiLabel returns the name of the source of the lines of a Loki stream:
+// the values of its labels, sorted by name and joined with "/".
+func LokiLabel(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = labels[name]
+	}
+	return strings.Join(values, "/")
+}
+
+// shutdownCloser closes an HTTP server once the requests in progress are
+// handled.
+type shutdownCloser struct {
+	srv *http.Server
+}
+
+func (s shutdownCloser) Close() error {
+	return s.srv.Shutdown(context.Background())
+}
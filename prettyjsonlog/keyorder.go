@@ -0,0 +1,72 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonKeyOrder returns the keys of the objects in a JSON document in the
+// order they appear, by dot separated path ("" for the top level object).
+// The objects in an array share the path of the array.
+func jsonKeyOrder(data []byte) map[string][]string {
+	order := map[string][]string{}
+	seen := map[string]bool{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for d.More() {
+				tok, err := d.Token()
+				if err != nil {
+					return err
+				}
+				key := tok.(string)
+				if !seen[path+"\x00"+key] {
+					seen[path+"\x00"+key] = true
+					order[path] = append(order[path], key)
+				}
+				child := key
+				if path != "" {
+					child = path + "." + key
+				}
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = d.Token()
+			return err
+		case json.Delim('['):
+			for d.More() {
+				if err := walk(path); err != nil {
+					return err
+				}
+			}
+			_, err = d.Token()
+			return err
+		}
+		return nil
+	}
+	walk("")
+	return order
+}
+
+// objectKeys returns the keys of an object of the line at path, in their
+// original order with --field-order original, or sorted.
+func (l *logLine) objectKeys(path string, m map[string]interface{}) []string {
+	order, ok := l.keyOrder[path]
+	if !ok {
+		return sortedKeys(m)
+	}
+	keys := make([]string, 0, len(m))
+	for _, k := range order {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
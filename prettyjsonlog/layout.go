@@ -0,0 +1,73 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// wrapIndent is the indentation of the continuation lines with --wrap.
+const wrapIndent = "    "
+
+// termWidth returns the width the lines are fitted to: the --width option,
+// or the width of the terminal. It returns 0 if unknown.
+func (p *PrettyJsonLog) termWidth() int {
+	if p.config.Width > 0 {
+		return p.config.Width
+	}
+	if f, ok := p.out.(*os.File); ok {
+		if w, _, err := term.GetSize(f.Fd()); err == nil {
+			return w
+		}
+	}
+	return 0
+}
+
+// fitLine writes the head of a line (time, level and message) and its
+// fields to b, truncating the line or wrapping the fields onto indented lines
+// to fit the terminal width with --truncate and --wrap.
+func (p *PrettyJsonLog) fitLine(b *bytes.Buffer, head string, fields []string) {
+	if !p.config.Wrap && !p.config.Truncate {
+		b.WriteString(head)
+		b.WriteByte(' ')
+		for i, field := range fields {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(field)
+		}
+		return
+	}
+	line := head + " " + strings.Join(fields, " ")
+	width := p.termWidth()
+	if width <= len(wrapIndent) || ansi.StringWidth(line) <= width {
+		b.WriteString(line)
+		return
+	}
+	if p.config.Truncate {
+		b.WriteString(ansi.Truncate(line, width, "…"))
+		return
+	}
+
+	cur, curWidth := head, ansi.StringWidth(head)
+	for _, field := range fields {
+		fieldWidth := ansi.StringWidth(field)
+		if curWidth+1+fieldWidth <= width {
+			cur += " " + field
+			curWidth += 1 + fieldWidth
+			continue
+		}
+		b.WriteString(cur + "\n")
+		// Fields longer than a line are wrapped too, keeping the indentation.
+		wrapped := strings.Split(ansi.Wrap(field, width-len(wrapIndent), " ,"), "\n")
+		for _, part := range wrapped[:len(wrapped)-1] {
+			b.WriteString(wrapIndent + part + "\n")
+		}
+		cur = wrapIndent + wrapped[len(wrapped)-1]
+		curWidth = ansi.StringWidth(cur)
+	}
+	b.WriteString(cur)
+}
@@ -0,0 +1,46 @@
+package prettyjsonlog
+
+import (
+	"errors"
+)
+
+// checkLimits rejects the invalid --head and --tail options.
+func (p *PrettyJsonLog) checkLimits() error {
+	c := p.config
+	if c.Head < 0 || c.Tail < 0 {
+		return errors.New("--head and --tail must be positive")
+	}
+	if c.Head > 0 && c.Tail > 0 {
+		return errors.New("--head and --tail can't be used together")
+	}
+	if (c.Head > 0 || c.Tail > 0) && c.TUI {
+		return errors.New("--head and --tail can't be used with --tui")
+	}
+	return nil
+}
+
+// tailRing keeps the last lines written to it, to be shown at the end with
+// --tail.
+type tailRing struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+func newTailRing(size int) *tailRing {
+	return &tailRing{lines: make([]string, size)}
+}
+
+func (r *tailRing) add(line string) {
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	r.full = r.full || r.next == 0
+}
+
+// take returns the lines kept, oldest first.
+func (r *tailRing) take() []string {
+	if !r.full {
+		return r.lines[:r.next]
+	}
+	return append(r.lines[r.next:len(r.lines):len(r.lines)], r.lines[:r.next]...)
+}
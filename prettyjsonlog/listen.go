@@ -0,0 +1,183 @@
+package prettyjsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Listen pretty prints the logs received on the addresses of the listen
+// option until an interrupt signal is received: udp://host:port receives a
+// line per datagram and tcp://host:port lines on each connection, raw JSON
+// lines or syslog messages. http://host:port receives NDJSON bodies POSTed
+// to any path, Loki push requests and OTLP logs. otlp://host:port is an
+// OTLP gRPC logs receiver.
+func (p *PrettyJsonLog) Listen() error {
+	sources := make(chan Source)
+	var closers []io.Closer
+	var wg sync.WaitGroup
+	for _, addr := range p.config.Listen {
+		u, err := url.Parse(addr)
+		if err != nil || u.Host == "" {
+			closeAll(closers)
+			return fmt.Errorf("invalid listen address %q (eg. udp://0.0.0.0:5514)", addr)
+		}
+		switch u.Scheme {
+		case "udp":
+			conn, err := net.ListenPacket("udp", u.Host)
+			if err != nil {
+				closeAll(closers)
+				return err
+			}
+			closers = append(closers, conn)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sources <- Source{Reader: packetReader(conn)}
+			}()
+		case "tcp":
+			l, err := net.Listen("tcp", u.Host)
+			if err != nil {
+				closeAll(closers)
+				return err
+			}
+			closers = append(closers, l)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.acceptLogs(l, sources)
+			}()
+		case "http":
+			l, err := net.Listen("tcp", u.Host)
+			if err != nil {
+				closeAll(closers)
+				return err
+			}
+			srv := &http.Server{Handler: p.ingestHandler(sources)}
+			closers = append(closers, shutdownCloser{srv})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := srv.Serve(l); err != http.ErrServerClosed {
+					log.Println(err)
+				}
+			}()
+		case "otlp":
+			if otlpReceiver == nil {
+				closeAll(closers)
+				return fmt.Errorf("unsupported listen address %q: the OTLP receiver isn't built in", addr)
+			}
+			l, err := net.Listen("tcp", u.Host)
+			if err != nil {
+				closeAll(closers)
+				return err
+			}
+			srv := otlpReceiver.NewGRPCServer(func(data []byte) {
+				sources <- Source{Reader: bytes.NewReader(append(data, '\n'))}
+			})
+			closers = append(closers, grpcCloser{srv})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := srv.Serve(l); err != nil {
+					log.Println(err)
+				}
+			}()
+		default:
+			closeAll(closers)
+			return fmt.Errorf("unsupported listen address %q (available: udp://, tcp://, http://, otlp://)", addr)
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(sources)
+	}()
+	var once sync.Once
+	return p.RunSources(sources, func() {
+		once.Do(func() { closeAll(closers) })
+	})
+}
+
+// acceptLogs sends the connections accepted by l as sources labeled with the
+// address of the peer, until l is closed. The connections are closed then.
+func (p *PrettyJsonLog) acceptLogs(l net.Listener, sources chan<- Source) {
+	var mu sync.Mutex
+	conns := map[net.Conn]bool{}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for conn := range conns {
+			conn.Close()
+		}
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conns[conn] = true
+		mu.Unlock()
+		sources <- Source{Name: conn.RemoteAddr().String(), Reader: lineReader(conn, func() {
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		})}
+	}
+}
+
+// packetReader returns the datagrams received on conn as lines, until conn
+// is closed.
+func packetReader(conn net.PacketConn) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				w.Close()
+				return
+			}
+			for _, line := range strings.Split(strings.TrimRight(string(buf[:n]), "\r\n"), "\n") {
+				if _, err := io.WriteString(w, stripOctetCount(line)+"\n"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return r
+}
+
+// lineReader returns the lines read from conn, without the octet counting
+// of the syslog messages, until conn is closed. done is called then.
+func lineReader(conn net.Conn, done func()) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		defer done()
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for s.Scan() {
+			if _, err := io.WriteString(w, stripOctetCount(s.Text())+"\n"); err != nil {
+				return
+			}
+		}
+		w.Close()
+	}()
+	return r
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errNotLogfmt = errors.New("not a logfmt line")
+
+// parseLogfmt parses a logfmt line (eg. `level=info msg="hello world" n=1`)
+// into the same representation as a decoded JSON line, so it can be rendered
+// the same way. Numbers and booleans are kept as such, everything else
+// becomes a string. Every token must be a key=value pair, otherwise the line
+// is not considered to be logfmt.
+func parseLogfmt(text string) (map[string]json.RawMessage, []string, error) {
+	line := map[string]json.RawMessage{}
+	var keys []string
+	s := strings.TrimSpace(text)
+	for s != "" {
+		eq := strings.IndexAny(s, "= \t\"")
+		if eq <= 0 || s[eq] != '=' {
+			return nil, nil, errNotLogfmt
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		if _, ok := line[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := closingQuote(s)
+			if end < 0 {
+				return nil, nil, errNotLogfmt
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, nil, errNotLogfmt
+			}
+			value = unquoted
+			s = s[end+1:]
+			if s != "" && s[0] != ' ' && s[0] != '\t' {
+				return nil, nil, errNotLogfmt
+			}
+			line[key], _ = json.Marshal(value)
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+			line[key] = logfmtValue(value)
+		}
+		s = strings.TrimLeft(s, " \t")
+	}
+	if len(line) == 0 {
+		return nil, nil, errNotLogfmt
+	}
+	return line, keys, nil
+}
+
+// closingQuote returns the index of the quote closing the quoted string at
+// the start of s, or -1 if it is not terminated.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func logfmtValue(v string) json.RawMessage {
+	switch {
+	case v == "true" || v == "false":
+		return json.RawMessage(v)
+	case v != "" && (v[0] == '-' || (v[0] >= '0' && v[0] <= '9')) && json.Valid([]byte(v)):
+		return json.RawMessage(v)
+	}
+	raw, _ := json.Marshal(v)
+	return raw
+}
@@ -0,0 +1,112 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// maxJSONLines bounds the number of lines joined into one JSON record, the
+// lines are passed on as is beyond it. It's kept small so that a truncated
+// record doesn't hold back the lines of a stream for long.
+const maxJSONLines = 200
+
+// jsonJoiner reassembles the JSON records pretty printed on several lines,
+// by balancing the braces and brackets outside of strings.
+type jsonJoiner struct {
+	lines []string
+	depth int
+}
+
+// add returns the lines complete after adding line: nothing while a record
+// is incomplete, then the record compacted onto one line. The lines of an
+// incomplete record are passed on as is when a line can't continue it, like
+// the line after a malformed or truncated one.
+func (j *jsonJoiner) add(line string) []string {
+	if len(j.lines) > 0 && !jsonContinuation(line) {
+		return append(j.flush(), j.add(line)...)
+	}
+	if len(j.lines) == 0 && !jsonStart(line) {
+		return []string{line}
+	}
+	j.lines = append(j.lines, line)
+	j.depth += jsonDepth(line)
+	if j.depth > 0 && len(j.lines) < maxJSONLines {
+		return nil
+	}
+	if len(j.lines) == 1 {
+		j.lines, j.depth = nil, 0
+		return []string{line}
+	}
+	var buf bytes.Buffer
+	if j.depth == 0 && json.Compact(&buf, []byte(strings.Join(j.lines, "\n"))) == nil {
+		j.lines = nil
+		return []string{buf.String()}
+	}
+	return j.flush()
+}
+
+// flush returns the lines of an incomplete record as is.
+func (j *jsonJoiner) flush() []string {
+	lines := j.lines
+	j.lines, j.depth = nil, 0
+	return lines
+}
+
+// jsonStart reports whether a line could start a JSON object: a brace
+// followed by nothing, a key or the closing brace.
+func jsonStart(line string) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return false
+	}
+	rest := strings.TrimSpace(line[1:])
+	return rest == "" || rest[0] == '"' || rest[0] == '}'
+}
+
+// jsonContinuation reports whether a line could be the next line of a
+// pretty printed JSON record: not a complete object at the start of the line,
+// and starting with a key, a value or a closing brace or bracket.
+func jsonContinuation(line string) bool {
+	if strings.HasPrefix(line, "{") && json.Valid([]byte(line)) {
+		return false
+	}
+	rest := strings.TrimSpace(line)
+	if rest == "" {
+		return true
+	}
+	switch c := rest[0]; {
+	case strings.IndexByte(`"{}[]-`, c) >= 0, c >= '0' && c <= '9':
+		return true
+	}
+	for _, literal := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(rest, literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonDepth returns the number of braces and brackets opened minus the
+// number closed in a line, outside of strings.
+func jsonDepth(line string) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return depth
+}
@@ -0,0 +1,59 @@
+package prettyjsonlog
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// stackFrameRe matches source locations in stack traces like "main.go:42",
+// "/app/index.js:12:5", "(Foo.java:123)" or the `"/app/worker.py", line 12`
+// of the Python tracebacks.
+var stackFrameRe = regexp.MustCompile(`[\w./\\@<>-]+\.\w+:\d+(:\d+)?|"[^"]+", line \d+`)
+
+// multilineBlock is a field value containing newlines (eg. a stack trace),
+// or a large object expanded with --expand, which is rendered as an indented
+// block below the log line.
+type multilineBlock struct {
+	path     string
+	value    string
+	expanded interface{}
+}
+
+func isMultiline(s string) bool {
+	return strings.Contains(strings.TrimRight(s, "\r\n"), "\n")
+}
+
+func (l *logLine) addBlock(path, value string) {
+	l.blocks = append(l.blocks, multilineBlock{path: path, value: value})
+}
+
+func (l *logLine) getBlocks() string {
+	sort.SliceStable(l.blocks, func(i, j int) bool { return l.blocks[i].path < l.blocks[j].path })
+	var sb strings.Builder
+	for _, b := range l.blocks {
+		sb.WriteString("    " + l.p.paint(l.p.theme.FieldKey, b.path+":") + "\n")
+		if b.expanded != nil {
+			sb.WriteString("      " + l.prettyJSON(b.path, b.expanded, "      ") + "\n")
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(b.value, "\r\n"), "\n") {
+			line = strings.TrimRight(line, "\r")
+			sb.WriteString("      " + l.formatStackLine(line) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatStackLine highlights the source locations in a line of a stack trace.
+func (l *logLine) formatStackLine(line string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range stackFrameRe.FindAllStringIndex(line, -1) {
+		sb.WriteString(l.p.paint(l.p.theme.Multiline, line[last:m[0]]))
+		sb.WriteString(l.p.paint(l.p.theme.Frame, line[m[0]:m[1]]))
+		last = m[1]
+	}
+	sb.WriteString(l.p.paint(l.p.theme.Multiline, line[last:]))
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parseNestedJSON replaces the string values holding a JSON object or array,
+// like "{\"id\":1}", by their decoded value, recursively.
+func parseNestedJSON(vi interface{}) interface{} {
+	switch v := vi.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		if len(s) < 2 || !(s[0] == '{' && s[len(s)-1] == '}' || s[0] == '[' && s[len(s)-1] == ']') {
+			return v
+		}
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		var decoded interface{}
+		if err := d.Decode(&decoded); err != nil || d.More() {
+			return v
+		}
+		return parseNestedJSON(decoded)
+	case map[string]interface{}:
+		for k, sub := range v {
+			v[k] = parseNestedJSON(sub)
+		}
+	case []interface{}:
+		for i, sub := range v {
+			v[i] = parseNestedJSON(sub)
+		}
+	}
+	return vi
+}
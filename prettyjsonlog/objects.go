@@ -0,0 +1,103 @@
+package prettyjsonlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Object is an object of a storage bucket, read as a part of an input.
+type Object struct {
+	Name string
+	Open func(ctx context.Context) (io.ReadCloser, error)
+}
+
+// objectStores list the objects of the input URLs of their schemes.
+var objectStores = map[string]func(ctx context.Context, u *url.URL) ([]Object, error){}
+
+// RegisterObjectStore registers the scheme of the input URLs naming objects
+// of a storage bucket, like s3://bucket/key. list returns the objects read,
+// in order: the object with the key, or the objects starting with it. It
+// must be called before Run, usually from an init function.
+func RegisterObjectStore(scheme string, list func(ctx context.Context, u *url.URL) ([]Object, error)) {
+	objectStores[scheme] = list
+}
+
+// isObjectURL tells if an input is the URL of objects of a storage bucket.
+func isObjectURL(name string) bool {
+	scheme, _, ok := strings.Cut(name, "://")
+	return ok && objectStores[scheme] != nil
+}
+
+// objectReader reads the objects named by an input URL one after another,
+// each one decompressed if needed.
+type objectReader struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	objects []Object
+	current io.Reader
+	closer  io.Closer
+}
+
+func openObjects(name string) (*objectReader, error) {
+	u, err := url.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	objects, err := objectStores[u.Scheme](ctx, u)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(objects) == 0 {
+		cancel()
+		return nil, fmt.Errorf("%s: no such object", name)
+	}
+	return &objectReader{ctx: ctx, cancel: cancel, objects: objects}, nil
+}
+
+func (o *objectReader) Read(b []byte) (int, error) {
+	for {
+		if o.current == nil {
+			if len(o.objects) == 0 {
+				return 0, io.EOF
+			}
+			obj := o.objects[0]
+			o.objects = o.objects[1:]
+			rc, err := obj.Open(o.ctx)
+			if err != nil {
+				return 0, fmt.Errorf("%s: %w", obj.Name, err)
+			}
+			r, err := decompress(rc)
+			if err != nil {
+				rc.Close()
+				return 0, fmt.Errorf("%s: %w", obj.Name, err)
+			}
+			// The objects may not end with a newline.
+			o.current, o.closer = io.MultiReader(r, strings.NewReader("\n")), rc
+		}
+		n, err := o.current.Read(b)
+		if err == io.EOF {
+			o.closer.Close()
+			o.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close stops reading the objects.
+func (o *objectReader) Close() error {
+	o.cancel()
+	if o.current != nil {
+		o.current = nil
+		return o.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otelLogsData is the OTLP JSON encoding of a batch of log records, as
+// exported by the OpenTelemetry SDKs and the file exporter of the collector.
+type otelLogsData struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []otelKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []otelLogRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type otelLogRecord struct {
+	TimeUnixNano         json.RawMessage `json:"timeUnixNano"`
+	ObservedTimeUnixNano json.RawMessage `json:"observedTimeUnixNano"`
+	// SeverityNumber is a number, or the name of the enum value like
+	// "SEVERITY_NUMBER_INFO" with the protobuf JSON mapping.
+	SeverityNumber json.RawMessage `json:"severityNumber"`
+	SeverityText   string          `json:"severityText"`
+	EventName      string          `json:"eventName"`
+	Body           *otelAnyValue   `json:"body"`
+	Attributes     []otelKeyValue  `json:"attributes"`
+	TraceID        string          `json:"traceId"`
+	SpanID         string          `json:"spanId"`
+}
+
+type otelKeyValue struct {
+	Key   string       `json:"key"`
+	Value otelAnyValue `json:"value"`
+}
+
+type otelAnyValue struct {
+	StringValue *string         `json:"stringValue"`
+	BoolValue   *bool           `json:"boolValue"`
+	IntValue    json.RawMessage `json:"intValue"`
+	DoubleValue *float64        `json:"doubleValue"`
+	BytesValue  *string         `json:"bytesValue"`
+	ArrayValue  *struct {
+		Values []otelAnyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []otelKeyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+// otelLevels are the levels of the ranges of 4 severity numbers, from 1.
+var otelLevels = [6]string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// otelRecords returns the lines of the OTLP log records of a line, a batch
+// like {"resourceLogs": [...]} or a single record, with the fields of their
+// attributes, the message of their body and the time and level at the keys
+// of the config. It returns false if the line isn't OTLP.
+func (p *PrettyJsonLog) otelRecords(text string, line *logLine) ([]string, bool) {
+	var records []otelLogRecord
+	var resources []map[string]interface{}
+	var scopes []string
+	if _, ok := line.line["resourceLogs"]; ok {
+		var data otelLogsData
+		if err := json.Unmarshal([]byte(text), &data); err != nil {
+			return nil, false
+		}
+		for _, rl := range data.ResourceLogs {
+			resource := otelAttributes(rl.Resource.Attributes)
+			for _, sl := range rl.ScopeLogs {
+				for _, r := range sl.LogRecords {
+					records = append(records, r)
+					resources = append(resources, resource)
+					scopes = append(scopes, sl.Scope.Name)
+				}
+			}
+		}
+	} else {
+		_, hasTime := line.line["timeUnixNano"]
+		_, hasObserved := line.line["observedTimeUnixNano"]
+		_, hasSeverity := line.line["severityNumber"]
+		_, hasBody := line.line["body"]
+		if !(hasTime || hasObserved) || !(hasSeverity || hasBody) {
+			return nil, false
+		}
+		var r otelLogRecord
+		if err := json.Unmarshal([]byte(text), &r); err != nil {
+			return nil, false
+		}
+		records, resources, scopes = []otelLogRecord{r}, []map[string]interface{}{nil}, []string{""}
+	}
+	lines := make([]string, 0, len(records))
+	for i, r := range records {
+		lines = append(lines, p.otelLine(r, resources[i], scopes[i]))
+	}
+	return lines, true
+}
+
+// otelLine returns a record as a JSON line, with its fields in the order
+// they're shown with --field-order original.
+func (p *PrettyJsonLog) otelLine(r otelLogRecord, resource map[string]interface{}, scope string) string {
+	var b bytes.Buffer
+	seen := map[string]bool{}
+	add := func(key string, value interface{}) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(value)
+		if err != nil {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	firstKey := func(keys string) string {
+		key, _, _ := strings.Cut(keys, ",")
+		return key
+	}
+	t, ok := otelTime(r.TimeUnixNano)
+	if !ok {
+		t, ok = otelTime(r.ObservedTimeUnixNano)
+	}
+	if ok {
+		add(firstKey(p.config.TimeFieldKey), t.Format(time.RFC3339Nano))
+	}
+	if level := otelLevel(r); level != "" {
+		add(firstKey(p.config.LevelFieldKey), level)
+	}
+	if r.Body != nil {
+		if body := r.Body.value(); body != nil {
+			if msg, ok := body.(string); ok {
+				add(firstKey(p.config.MessageFieldKey), msg)
+			} else {
+				add("body", body)
+			}
+		}
+	}
+	if r.EventName != "" {
+		add("event", r.EventName)
+	}
+	for _, kv := range r.Attributes {
+		add(kv.Key, kv.Value.value())
+	}
+	if r.TraceID != "" {
+		add("traceId", r.TraceID)
+	}
+	if r.SpanID != "" {
+		add("spanId", r.SpanID)
+	}
+	if scope != "" {
+		add("scope", scope)
+	}
+	if len(resource) > 0 {
+		add("resource", resource)
+	}
+	return "{" + b.String() + "}"
+}
+
+// otelTime parses a time in nanoseconds since the epoch, a string or a
+// number. It returns false for a missing or zero time.
+func otelTime(raw json.RawMessage) (time.Time, bool) {
+	n, err := strconv.ParseInt(strings.Trim(string(raw), `"`), 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n).UTC(), true
+}
+
+// otelLevel returns the level of the range of the severity number of a
+// record, or its severity text if it has no number.
+func otelLevel(r otelLogRecord) string {
+	s := strings.Trim(string(r.SeverityNumber), `"`)
+	if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= 24 {
+		return otelLevels[(n-1)/4]
+	}
+	if r.SeverityText != "" {
+		return r.SeverityText
+	}
+	if name, ok := strings.CutPrefix(s, "SEVERITY_NUMBER_"); ok && name != "UNSPECIFIED" {
+		return strings.TrimRight(strings.ToLower(name), "1234")
+	}
+	return ""
+}
+
+// otelAttributes returns key/value pairs as fields.
+func otelAttributes(kvs []otelKeyValue) map[string]interface{} {
+	fields := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		fields[kv.Key] = kv.Value.value()
+	}
+	return fields
+}
+
+// value returns the value held, nil if none.
+func (v otelAnyValue) value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		n, err := strconv.ParseInt(strings.Trim(string(v.IntValue), `"`), 10, 64)
+		if err != nil {
+			return string(v.IntValue)
+		}
+		return n
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, e := range v.ArrayValue.Values {
+			values = append(values, e.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		return otelAttributes(v.KvlistValue.Values)
+	}
+	return nil
+}
+
+// OTLPReceiver receives the OTLP logs in the protobuf encoding, posted to
+// the /v1/logs path of the HTTP listener or exported over gRPC to the
+// otlp:// listener.
+type OTLPReceiver interface {
+	// DecodeLogs returns the JSON encoding of an ExportLogsServiceRequest
+	// in the protobuf encoding, on a single line.
+	DecodeLogs(body []byte) ([]byte, error)
+	// NewGRPCServer returns a server of the gRPC logs service calling export
+	// with the JSON encoding of each request.
+	NewGRPCServer(export func(data []byte)) GRPCServer
+}
+
+// GRPCServer is a gRPC server, like *grpc.Server.
+type GRPCServer interface {
+	Serve(l net.Listener) error
+	GracefulStop()
+}
+
+// otlpReceiver is the OTLP receiver registered, if any.
+var otlpReceiver OTLPReceiver
+
+// RegisterOTLPReceiver registers the receiver of the OTLP logs in the
+// protobuf encoding. It must be called before Listen, usually from an init
+// function.
+func RegisterOTLPReceiver(r OTLPReceiver) {
+	otlpReceiver = r
+}
+
+// ingestOTLP receives the OTLP logs posted to /v1/logs, in JSON or protobuf,
+// as a line.
+func ingestOTLP(w http.ResponseWriter, contentType string, body []byte, sources chan<- Source) {
+	var data []byte
+	switch contentType {
+	case "application/json":
+		var b bytes.Buffer
+		if err := json.Compact(&b, body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data = b.Bytes()
+	case "application/x-protobuf":
+		if otlpReceiver == nil {
+			http.Error(w, "only JSON is supported", http.StatusUnsupportedMediaType)
+			return
+		}
+		var err error
+		if data, err = otlpReceiver.DecodeLogs(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "only JSON and protobuf are supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	sources <- Source{Reader: bytes.NewReader(append(data, '\n'))}
+	// The response is an empty ExportLogsServiceResponse, in the encoding of
+	// the request.
+	w.Header().Set("Content-Type", contentType)
+	if contentType == "application/json" {
+		io.WriteString(w, "{}")
+	}
+}
+
+// grpcCloser stops a gRPC server once the requests in progress are handled.
+type grpcCloser struct {
+	srv GRPCServer
+}
+
+func (g grpcCloser) Close() error {
+	g.srv.GracefulStop()
+	return nil
+}
@@ -0,0 +1,70 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// flushInterval bounds the time the rendered lines stay buffered while more
+// lines are waiting to be rendered.
+const flushInterval = 100 * time.Millisecond
+
+// openOut creates the --out file the rendered lines are duplicated to, and
+// returns whether it's written with colors.
+func (p *PrettyJsonLog) openOut() (bool, error) {
+	if p.config.Out == "" {
+		return false, nil
+	}
+	var colored bool
+	switch p.config.OutColor {
+	case "", "never":
+	case "always":
+		colored = true
+	default:
+		return false, fmt.Errorf("invalid out color mode %q (available: always, never)", p.config.OutColor)
+	}
+	f, err := os.Create(p.config.Out)
+	if err != nil {
+		return false, err
+	}
+	p.outFile = f
+	return colored, nil
+}
+
+// output returns the writer of the rendered lines: the output and the --out
+// file, each one without colors unless enabled for it. The lines are
+// rendered with colors if enabled for either of them. The footer, if any, is
+// erased before the lines are written to the output.
+func (p *PrettyJsonLog) output() io.Writer {
+	var w io.Writer = p.out
+	if p.stripColors {
+		w = stripWriter{w}
+	}
+	if p.footer != nil {
+		w = p.footer
+	}
+	if p.outFile == nil {
+		return w
+	}
+	var f io.Writer = p.outFile
+	if !p.outColor {
+		f = stripWriter{f}
+	}
+	return io.MultiWriter(w, f)
+}
+
+// stripWriter removes the escape sequences of the text written to w.
+type stripWriter struct {
+	w io.Writer
+}
+
+func (s stripWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(s.w, ansi.Strip(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,93 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+// pauser lets the user pause and resume the output by pressing space in the
+// terminal. Lines keep being read and are buffered while paused.
+type pauser struct {
+	tty      *os.File
+	restore  func()
+	resumeCh chan struct{}
+
+	mu     sync.Mutex
+	paused bool
+}
+
+// startPauser starts listening for key presses on the terminal. It returns
+// nil if stdout is not a terminal or the terminal can't be used, and if
+// stdin is the terminal: the keys are then typed for pretty-json-log or for
+// the command it runs.
+func startPauser() *pauser {
+	if !isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil
+	}
+	restore, err := setCbreak(int(tty.Fd()))
+	if err != nil {
+		tty.Close()
+		return nil
+	}
+	ps := &pauser{tty: tty, restore: restore, resumeCh: make(chan struct{}, 1)}
+	go ps.readKeys()
+	return ps
+}
+
+func (ps *pauser) readKeys() {
+	b := make([]byte, 1)
+	for {
+		if _, err := ps.tty.Read(b); err != nil {
+			return
+		}
+		if b[0] != ' ' {
+			continue
+		}
+		ps.mu.Lock()
+		ps.paused = !ps.paused
+		paused := ps.paused
+		ps.mu.Unlock()
+		if paused {
+			fmt.Fprint(os.Stderr, "-- PAUSED, press space to resume --")
+		} else {
+			fmt.Fprint(os.Stderr, "\r\x1b[K")
+			select {
+			case ps.resumeCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (ps *pauser) isPaused() bool {
+	if ps == nil {
+		return false
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.paused
+}
+
+// resumed returns a channel receiving a value each time the output is
+// resumed.
+func (ps *pauser) resumed() <-chan struct{} {
+	if ps == nil {
+		return nil
+	}
+	return ps.resumeCh
+}
+
+func (ps *pauser) stop() {
+	if ps == nil {
+		return
+	}
+	ps.restore()
+	ps.tty.Close()
+}
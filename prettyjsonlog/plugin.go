@@ -0,0 +1,221 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parser turns the lines that are neither JSON nor logfmt into fields, for
+// the log formats of other loggers.
+type Parser interface {
+	// Parse returns the fields of a line, and false if it's not in the
+	// format of the parser. It may be called concurrently.
+	Parse(text string) (map[string]interface{}, bool)
+}
+
+// Record is a parsed line given to a Renderer.
+type Record struct {
+	// Time is zero if the line has no time.
+	Time    time.Time
+	Level   string
+	Message string
+	Source  string
+	// Fields are the other fields of the line, with the numbers as
+	// json.Number.
+	Fields map[string]interface{}
+}
+
+// Renderer renders the lines instead of the default layout.
+type Renderer interface {
+	// Render returns the text shown for a line, without the trailing
+	// newline. It may be called concurrently.
+	Render(r Record) (string, error)
+}
+
+// parsers and renderers are the plugins of the --parser and --renderer
+// options by name.
+var (
+	parsers   = map[string]Parser{}
+	renderers = map[string]Renderer{}
+)
+
+// RegisterParser registers a parser of the parser option. It must be called
+// before NewPrettyJsonLog, usually from an init function.
+func RegisterParser(name string, p Parser) {
+	parsers[name] = p
+}
+
+// RegisterRenderer registers a renderer of the renderer option. It must be
+// called before NewPrettyJsonLog, usually from an init function.
+func RegisterRenderer(name string, r Renderer) {
+	renderers[name] = r
+}
+
+// pluginNames returns the names of the registered plugins and the other
+// names given, for the errors.
+func pluginNames[T any](plugins map[string]T, names ...string) string {
+	names = append(names, "exec:command")
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// newParser returns the parser of a --parser option: a pattern of the
+// config, a registered parser, grok:pattern for a grokParser or
+// exec:command for an execParser.
+func (p *PrettyJsonLog) newParser(spec string) (Parser, error) {
+	if command, ok := strings.CutPrefix(spec, "exec:"); ok {
+		return &execParser{cmd: newTransformer(command, p.config.Workers)}, nil
+	}
+	pattern, ok := strings.CutPrefix(spec, "grok:")
+	if !ok {
+		pattern, ok = p.config.Patterns[spec]
+	}
+	if ok {
+		parser, err := newGrokParser(pattern, p.config.GrokPatterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parser %q: %w", spec, err)
+		}
+		return parser, nil
+	}
+	parser, ok := parsers[spec]
+	if !ok {
+		names := []string{"grok:pattern"}
+		for name := range p.config.Patterns {
+			names = append(names, name)
+		}
+		return nil, fmt.Errorf("unknown parser %q (available: %s)", spec, pluginNames(parsers, names...))
+	}
+	return parser, nil
+}
+
+// newRenderer returns the renderer of the --renderer option: a registered
+// renderer, or exec:command for an execRenderer.
+func (p *PrettyJsonLog) newRenderer(spec string) (Renderer, error) {
+	if command, ok := strings.CutPrefix(spec, "exec:"); ok {
+		return &execRenderer{cmd: newTransformer(command, p.config.Workers)}, nil
+	}
+	renderer, ok := renderers[spec]
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer %q (available: %s)", spec, pluginNames(renderers))
+	}
+	return renderer, nil
+}
+
+// parse returns the line parsed by the first of the parsers recognizing it.
+func (p *PrettyJsonLog) parse(text string) (*logLine, bool) {
+	for _, parser := range p.parsers {
+		fields, ok := parser.Parse(text)
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			continue
+		}
+		if line, err := NewLogLine(string(data), p); err == nil {
+			return line, true
+		}
+	}
+	return nil, false
+}
+
+// renderRecord renders a line with the renderer, the line is rendered with
+// the default layout if it fails.
+func (p *PrettyJsonLog) renderRecord(line *logLine, entry logEntry, level string) (string, bool) {
+	r := Record{Level: level, Source: entry.source}
+	r.Time, _ = line.parsedTime()
+	msg, msgKey := line.message()
+	r.Message = msg
+	fields := line.decodedNumbers()
+	if _, levelKey := line.level(); levelKey != "" {
+		deletePath(fields, levelKey)
+	}
+	if _, timeKey, _ := line.time(); timeKey != "" {
+		deletePath(fields, timeKey)
+	}
+	deletePath(fields, msgKey)
+	r.Fields = fields
+	out, err := p.renderer.Render(r)
+	if err != nil {
+		p.pluginErr.Do(func() { log.Printf("renderer: %v", err) })
+		return "", false
+	}
+	return out + "\n", true
+}
+
+// execParser runs a command parsing the lines: it reads a JSON object with
+// the line for each line, like {"line": "..."}, and writes back the fields
+// parsed as a JSON object, or null if it doesn't recognize the line.
+type execParser struct {
+	cmd    *transformer
+	failed sync.Once
+}
+
+func (e *execParser) Parse(text string) (map[string]interface{}, bool) {
+	data, err := json.Marshal(map[string]string{"line": text})
+	if err != nil {
+		return nil, false
+	}
+	out, err := e.cmd.call(data)
+	if err == nil && out != "" && out != "null" {
+		var fields map[string]interface{}
+		if err = json.Unmarshal([]byte(out), &fields); err == nil {
+			return fields, fields != nil
+		}
+	}
+	if err != nil {
+		e.failed.Do(func() { log.Printf("parser: %v", err) })
+	}
+	return nil, false
+}
+
+// execRenderer runs a command rendering the lines: it reads a JSON object
+// for each line, with its time (RFC 3339, omitted if unknown), level, msg,
+// source and the other fields, like {"time": "...", "level": "INFO", "msg":
+// "...", "source": "", "fields": {...}}, and writes back the text shown as a
+// JSON object, like {"text": "..."}.
+type execRenderer struct {
+	cmd *transformer
+}
+
+func (e *execRenderer) Render(r Record) (string, error) {
+	in := map[string]interface{}{"level": r.Level, "msg": r.Message, "source": r.Source, "fields": r.Fields}
+	if !r.Time.IsZero() {
+		in["time"] = r.Time.Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	out, err := e.cmd.call(data)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		return "", fmt.Errorf("invalid output %q: %w", out, err)
+	}
+	return res.Text, nil
+}
+
+// closePlugins ends the commands of the exec plugins, if any.
+func (p *PrettyJsonLog) closePlugins() {
+	for _, parser := range p.parsers {
+		if e, ok := parser.(*execParser); ok {
+			e.cmd.close()
+		}
+	}
+	if e, ok := p.renderer.(*execRenderer); ok {
+		e.cmd.close()
+	}
+}
@@ -0,0 +1,243 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Preset configures the fields and levels used by a specific logger. Empty
+// settings fall back to the "default" preset.
+type Preset struct {
+	TimeFieldKey    string         `yaml:"time-field"`
+	LevelFieldKey   string         `yaml:"level-field"`
+	MessageFieldKey string         `yaml:"message-field"`
+	Levels          map[int]string `yaml:"levels"`
+	// LevelAliases maps level names to the canonical ones (eg. WARNING: WARN).
+	LevelAliases map[string]string `yaml:"level-aliases"`
+	// EpochUnit, ComponentField, ComponentLength and HiddenFields are the
+	// defaults of the settings of the same names.
+	EpochUnit       string   `yaml:"epoch-unit"`
+	ComponentField  string   `yaml:"component-field"`
+	ComponentLength int      `yaml:"component-length"`
+	HiddenFields    []string `yaml:"hide-fields"`
+}
+
+var bunyanLevels = map[int]string{
+	10: "trace",
+	20: "debug",
+	30: "info",
+	40: "warn",
+	50: "error",
+	60: "fatal",
+}
+
+// syslogLevels are the syslog severities.
+var syslogLevels = map[int]string{
+	0: "emerg",
+	1: "alert",
+	2: "crit",
+	3: "err",
+	4: "warning",
+	5: "notice",
+	6: "info",
+	7: "debug",
+}
+
+var builtinPresets = map[string]Preset{
+	"default": {
+		TimeFieldKey:    "time,timestamp",
+		LevelFieldKey:   "level,lvl",
+		MessageFieldKey: "message,msg",
+		Levels:          bunyanLevels,
+		LevelAliases:    map[string]string{"warning": "warn"},
+	},
+	"bunyan": {
+		TimeFieldKey:    "time",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "msg",
+		Levels:          bunyanLevels,
+	},
+	"pino": {
+		TimeFieldKey:    "time",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "msg",
+		Levels:          bunyanLevels,
+	},
+	"zap": {
+		TimeFieldKey:    "ts",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "msg",
+		Levels:          map[int]string{-1: "debug", 0: "info", 1: "warn", 2: "error", 3: "panic", 4: "panic", 5: "fatal"},
+	},
+	"zerolog": {
+		TimeFieldKey:    "time",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "message",
+		Levels:          map[int]string{-1: "trace", 0: "debug", 1: "info", 2: "warn", 3: "error", 4: "fatal", 5: "panic"},
+	},
+	"gcp": {
+		TimeFieldKey:    "timestamp,time",
+		LevelFieldKey:   "severity",
+		MessageFieldKey: "message,textPayload",
+		Levels: map[int]string{
+			0:   "default",
+			100: "debug",
+			200: "info",
+			300: "notice",
+			400: "warning",
+			500: "error",
+			600: "critical",
+			700: "alert",
+			800: "emergency",
+		},
+		LevelAliases: map[string]string{
+			"notice":    "info",
+			"warning":   "warn",
+			"critical":  "fatal",
+			"alert":     "fatal",
+			"emergency": "panic",
+		},
+	},
+	"journald": {
+		TimeFieldKey:    "__REALTIME_TIMESTAMP",
+		LevelFieldKey:   "PRIORITY",
+		MessageFieldKey: "MESSAGE",
+		Levels:          syslogLevels,
+		LevelAliases: map[string]string{
+			"emerg":  "panic",
+			"alert":  "fatal",
+			"crit":   "fatal",
+			"err":    "error",
+			"notice": "info",
+		},
+		EpochUnit:      "us",
+		ComponentField: "_SYSTEMD_USER_UNIT,_SYSTEMD_UNIT,SYSLOG_IDENTIFIER",
+		// The trusted fields added by journald (_PID, _BOOT_ID...) and the
+		// syslog ones are noise next to the message.
+		HiddenFields: []string{"_*", "SYSLOG_*"},
+	},
+	"ecs": {
+		TimeFieldKey:    "@timestamp",
+		LevelFieldKey:   "log.level",
+		MessageFieldKey: "message",
+		Levels:          bunyanLevels,
+		LevelAliases:    map[string]string{"critical": "fatal"},
+		ComponentField:  "log.logger,service.name",
+		HiddenFields:    []string{"ecs", "ecs.version"},
+	},
+	// The JSON of logstash-logback-encoder, the usual one of Spring Boot
+	// services, with the logger names abbreviated like %logger{36} of
+	// Logback. The stack_trace field is shown as a block below the line.
+	"logstash": {
+		TimeFieldKey:    "@timestamp",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "message",
+		Levels:          bunyanLevels,
+		LevelAliases:    map[string]string{"warning": "warn"},
+		ComponentField:  "logger_name",
+		ComponentLength: 36,
+		HiddenFields:    []string{"@version", "level_value"},
+	},
+	// The compact JSON of Serilog (CLEF), whose lines are shown with the
+	// message template rendered whatever the preset. This one keeps the keys
+	// of CLEF with --output json.
+	"serilog": {
+		TimeFieldKey:    "@t",
+		LevelFieldKey:   "@l",
+		MessageFieldKey: "@m",
+		Levels:          bunyanLevels,
+		LevelAliases:    map[string]string{"verbose": "trace", "information": "info", "warning": "warn"},
+	},
+	// python-json-logger and structlog, the numeric levels are the ones of
+	// the logging module. The tracebacks of exc_info and exception are shown
+	// as blocks below the line.
+	"python": {
+		TimeFieldKey:    "asctime,created,timestamp",
+		LevelFieldKey:   "levelname,level,levelno",
+		MessageFieldKey: "message,event,msg",
+		Levels:          map[int]string{5: "trace", 10: "debug", 20: "info", 30: "warning", 40: "error", 50: "critical"},
+		LevelAliases:    map[string]string{"warning": "warn", "critical": "fatal", "exception": "error"},
+		ComponentField:  "name,logger",
+		HiddenFields:    []string{"levelno"},
+	},
+	"logrus": {
+		TimeFieldKey:    "time",
+		LevelFieldKey:   "level",
+		MessageFieldKey: "msg",
+		Levels:          map[int]string{0: "panic", 1: "fatal", 2: "error", 3: "warn", 4: "info", 5: "debug", 6: "trace"},
+	},
+}
+
+// PresetNames returns the names of the built-in presets.
+func PresetNames() []string {
+	var names []string
+	for name := range builtinPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolvePreset returns the preset selected in the config, with the presets
+// defined in the config file taking precedence over the built-in ones and the
+// levels of the config added to the preset's.
+func (c *PrettyJsonLogConfig) resolvePreset() (Preset, error) {
+	name := c.Preset
+	if name == "" {
+		name = "default"
+	}
+	selected, ok := c.Presets[name]
+	if !ok {
+		selected, ok = builtinPresets[name]
+	}
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	preset := builtinPresets["default"]
+	if selected.TimeFieldKey != "" {
+		preset.TimeFieldKey = selected.TimeFieldKey
+	}
+	if selected.LevelFieldKey != "" {
+		preset.LevelFieldKey = selected.LevelFieldKey
+	}
+	if selected.MessageFieldKey != "" {
+		preset.MessageFieldKey = selected.MessageFieldKey
+	}
+	if selected.Levels != nil {
+		preset.Levels = selected.Levels
+	}
+	if selected.EpochUnit != "" {
+		preset.EpochUnit = selected.EpochUnit
+	}
+	if selected.ComponentField != "" {
+		preset.ComponentField = selected.ComponentField
+	}
+	if selected.ComponentLength != 0 {
+		preset.ComponentLength = selected.ComponentLength
+	}
+	if selected.HiddenFields != nil {
+		preset.HiddenFields = selected.HiddenFields
+	}
+	if c.Levels != nil {
+		levels := map[int]string{}
+		for k, v := range preset.Levels {
+			levels[k] = v
+		}
+		for k, v := range c.Levels {
+			levels[k] = v
+		}
+		preset.Levels = levels
+	}
+	if selected.LevelAliases != nil {
+		aliases := map[string]string{}
+		for k, v := range preset.LevelAliases {
+			aliases[k] = v
+		}
+		for k, v := range selected.LevelAliases {
+			aliases[k] = v
+		}
+		preset.LevelAliases = aliases
+	}
+	return preset, nil
+}
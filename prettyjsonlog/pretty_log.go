@@ -0,0 +1,1661 @@
+// Package prettyjsonlog parses JSON logs and formats them in an easily
+// readable way with colors.
+package prettyjsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/araddon/dateparse"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+)
+
+type PrettyJsonLog struct {
+	config PrettyJsonLogConfig
+	out    io.Writer
+
+	theme             *Theme
+	useColor          bool
+	format            *template.Template
+	intLevels         map[int]string
+	levelAliases      map[string]string
+	levelSeverity     map[string]int
+	minSeverity       int
+	filter            *lineFilter
+	grep              *regexp.Regexp
+	grepV             *regexp.Regexp
+	displayTimeFormat string
+
+	// timeKeys, levelKeys and messageKeys are the keys of the config split
+	// once rather than for every line.
+	timeKeys    []string
+	levelKeys   []string
+	messageKeys []string
+
+	// timeLayouts are the Go layouts the times are parsed with, dateparse
+	// guesses the layout if empty.
+	timeLayouts []string
+	// failSeverity is the severity of --fail-on, 0 if disabled. failed is
+	// set when a line at or above it is read.
+	failSeverity int
+	failed       atomic.Bool
+
+	// thinner samples and rate limits the lines below WARN, nil if
+	// disabled.
+	thinner *thinner
+	// redactor hides secrets in the lines, nil if disabled.
+	redactor *redactor
+	// highlightRules color the values of the fields matching a condition.
+	highlightRules []*highlightRule
+	// highlights are the --highlight patterns.
+	highlights []highlightPattern
+	// grouper marks the lines of the same --group-by group, nil if disabled.
+	grouper *grouper
+	// fieldPriority maps the priority fields to their position.
+	fieldPriority map[string]int
+	// dedupe collapses repeated lines, nil if disabled.
+	dedupe *deduper
+	// gaps marks the quiet periods, nil if disabled.
+	gaps *gapDetector
+	// skew finds the times out of order or in the future, nil if
+	// disabled.
+	skew *skewDetector
+	// alerter rings the bell on the lines matching --alert, nil if
+	// disabled.
+	alerter *alerter
+	// execHook runs the --exec command on the lines matching
+	// --exec-on-match, nil if disabled.
+	execHook *execHook
+	// webhook posts the lines matching --webhook-on-match, nil if
+	// disabled.
+	webhook *webhook
+	// transformer pipes the lines through --transform-cmd, nil if
+	// disabled.
+	transformer *transformer
+	// script changes the fields of the lines with --script, nil if
+	// disabled. scriptErr logs its first error.
+	script    Script
+	scriptErr sync.Once
+	// parsers parse the lines that are neither JSON nor logfmt, and
+	// renderer renders the lines if set. pluginErr logs the first error of
+	// the renderer.
+	parsers   []Parser
+	renderer  Renderer
+	pluginErr sync.Once
+	// aggregator counts the lines instead of showing them with RunStats,
+	// nil otherwise.
+	aggregator *aggregator
+
+	// stats counts the lines for the summary and the footer, nil if both
+	// are disabled.
+	stats *stats
+	// footer shows counters below the lines, nil if disabled.
+	footer *footer
+
+	// since and until are the time range of the lines shown, if not zero.
+	since time.Time
+	until time.Time
+	// location is the timezone times are shown in, nil to keep the timezone
+	// of the log line.
+	location *time.Location
+
+	// In relative mode times are shown as offsets from timeOrigin, the time
+	// of the first line or the start of the process.
+	relativeTime bool
+	timeMu       sync.Mutex
+	timeOrigin   time.Time
+	// prevTime is the time of the previous line, for the time deltas.
+	prevTime time.Time
+
+	// outFile is the --out file, written with colors if outColor is set.
+	// stripColors is set when the lines are rendered with colors for it
+	// only.
+	outFile     *os.File
+	outColor    bool
+	stripColors bool
+
+	// sink stores the parsed lines, nil if disabled. sinkErr logs its
+	// first error.
+	sink    Sink
+	sinkErr sync.Once
+
+	// sourceLabels holds the rendered label prefixed to the lines of each
+	// input, by input name.
+	sourceLabels map[string]string
+	labelWidth   int
+	labelsMu     sync.RWMutex
+
+	// sequences caches the escape sequences of the colors painted with, by
+	// color.
+	sequences sync.Map
+}
+
+// NewPrettyJsonLog creates a PrettyJsonLog printing to stdout.
+func NewPrettyJsonLog(config PrettyJsonLogConfig) (*PrettyJsonLog, error) {
+	return newPrettyJsonLog(config, os.Stdout)
+}
+
+func newPrettyJsonLog(config PrettyJsonLogConfig, out io.Writer) (*PrettyJsonLog, error) {
+	preset, err := config.resolvePreset()
+	if err != nil {
+		return nil, err
+	}
+	if config.TimeFieldKey == "" {
+		config.TimeFieldKey = preset.TimeFieldKey
+	}
+	if config.LevelFieldKey == "" {
+		config.LevelFieldKey = preset.LevelFieldKey
+	}
+	if config.MessageFieldKey == "" {
+		config.MessageFieldKey = preset.MessageFieldKey
+	}
+	if (config.EpochUnit == "" || config.EpochUnit == "auto") && preset.EpochUnit != "" {
+		config.EpochUnit = preset.EpochUnit
+	}
+	if config.ComponentField == "" {
+		config.ComponentField = preset.ComponentField
+	}
+	if config.ComponentLength == 0 {
+		config.ComponentLength = preset.ComponentLength
+	}
+	if config.HiddenFields == nil {
+		config.HiddenFields = preset.HiddenFields
+	}
+	if config.OutputTimeFmt == "" {
+		config.OutputTimeFmt = "{t}{ms}"
+	}
+	if config.ExpandSize == 0 {
+		config.ExpandSize = 8
+	}
+	if config.ExpandDepth == 0 {
+		config.ExpandDepth = 2
+	}
+	if config.CallerSegments == 0 {
+		config.CallerSegments = 2
+	}
+	if config.EditorURL == "" {
+		config.EditorURL = defaultEditorURL
+	}
+	if len(config.TraceFields) == 0 {
+		config.TraceFields = defaultTraceFields
+	}
+
+	dateFormatReplacer := strings.NewReplacer("{d}", "2006-01-02", "{t}", "15:04:05", "{ms}", ".000")
+
+	p := &PrettyJsonLog{
+		config:            config,
+		out:               out,
+		displayTimeFormat: dateFormatReplacer.Replace(config.OutputTimeFmt),
+		timeKeys:          strings.Split(config.TimeFieldKey, ","),
+		levelKeys:         strings.Split(config.LevelFieldKey, ","),
+		messageKeys:       strings.Split(config.MessageFieldKey, ","),
+
+		intLevels:    preset.Levels,
+		levelAliases: map[string]string{},
+		levelSeverity: map[string]int{
+			"TRACE": 10,
+			"DEBUG": 20,
+			"INFO":  30,
+			"WARN":  40,
+			"ERROR": 50,
+			"FATAL": 60,
+			"PANIC": 70,
+		},
+	}
+	for _, layout := range config.TimeLayouts {
+		p.timeLayouts = append(p.timeLayouts, goTimeLayout(layout))
+	}
+	if p.since, err = parseTimeBound(config.Since); err != nil {
+		return nil, fmt.Errorf("invalid since: %w", err)
+	}
+	if p.until, err = parseTimeBound(config.Until); err != nil {
+		return nil, fmt.Errorf("invalid until: %w", err)
+	}
+	if config.Sample != "" || config.RateLimit != "" {
+		if p.thinner, err = newThinner(p, config.Sample, config.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	if config.Redact || len(config.RedactKeys) > 0 || len(config.RedactValues) > 0 {
+		if p.redactor, err = newRedactor(config.Redact, config.RedactKeys, config.RedactValues); err != nil {
+			return nil, err
+		}
+	}
+	p.fieldPriority = map[string]int{}
+	for i, k := range config.PriorityFields {
+		p.fieldPriority[k] = i
+	}
+	if config.Dedupe {
+		p.dedupe = &deduper{p: p}
+	}
+	if config.GroupBy != "" {
+		p.grouper = &grouper{p: p, seen: map[string]bool{}}
+	}
+	if config.Gap > 0 {
+		p.gaps = &gapDetector{p: p}
+	}
+	if config.WarnSkew > 0 {
+		p.skew = &skewDetector{threshold: config.WarnSkew}
+	}
+	if config.Summary {
+		p.stats = newStats()
+	}
+	switch config.Timezone {
+	case "", "local", "Local":
+		p.location = time.Local
+	case "original":
+	default:
+		if p.location, err = time.LoadLocation(config.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if _, ok := epochUnits[config.EpochUnit]; !ok && config.EpochUnit != "" && config.EpochUnit != "auto" {
+		return nil, fmt.Errorf("invalid epoch unit %q (available: auto, s, ms, us, ns)", config.EpochUnit)
+	}
+	switch config.OutputTimeFmt {
+	case "relative":
+		p.relativeTime = true
+	case "relative-start":
+		p.relativeTime = true
+		p.timeOrigin = time.Now()
+	}
+	for alias, level := range preset.LevelAliases {
+		p.levelAliases[strings.ToUpper(alias)] = strings.ToUpper(level)
+	}
+	for alias, level := range config.LevelAliases {
+		p.levelAliases[strings.ToUpper(alias)] = strings.ToUpper(level)
+	}
+	colors := map[string]string{}
+	for name, level := range config.CustomLevels {
+		p.levelSeverity[strings.ToUpper(name)] = level.Severity
+		if level.Color != "" {
+			colors[strings.ToUpper(name)] = level.Color
+		}
+	}
+	for key, spec := range config.Colors {
+		colors[key] = spec
+	}
+	if config.MinLevel != "" {
+		if p.minSeverity, err = p.parseSeverity(config.MinLevel); err != nil {
+			return nil, fmt.Errorf("invalid min level: %w", err)
+		}
+	}
+	if config.FailOn != "" {
+		if p.failSeverity, err = p.parseSeverity(config.FailOn); err != nil {
+			return nil, fmt.Errorf("invalid fail-on level: %w", err)
+		}
+	}
+	if config.Filter != "" {
+		if p.filter, err = newLineFilter(config.Filter); err != nil {
+			return nil, err
+		}
+	}
+	if config.Alert != "" {
+		if p.alerter, err = p.newAlerter(config.Alert, config.AlertNotify); err != nil {
+			return nil, err
+		}
+	}
+	if (config.ExecOnMatch == "") != (config.Exec == "") {
+		return nil, errors.New("--exec-on-match and --exec must be used together")
+	}
+	if (config.WebhookOnMatch == "") != (config.Webhook == "") {
+		return nil, errors.New("--webhook-on-match and --webhook must be used together")
+	}
+	if config.Grep != "" {
+		if p.grep, err = regexp.Compile(config.Grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+	if config.GrepV != "" {
+		if p.grepV, err = regexp.Compile(config.GrepV); err != nil {
+			return nil, fmt.Errorf("invalid grep-v pattern: %w", err)
+		}
+	}
+	if config.Wrap && config.Truncate {
+		return nil, errors.New("--wrap and --truncate can't be used together")
+	}
+	if err := p.checkWorkers(); err != nil {
+		return nil, err
+	}
+	if err := checkDropPolicy(config.DropPolicy); err != nil {
+		return nil, err
+	}
+	if err := p.checkLimits(); err != nil {
+		return nil, err
+	}
+	if len(config.ByteFields) == 0 {
+		p.config.ByteFields = defaultByteFields
+	}
+	switch config.Bytes {
+	case "", "raw", "human", "both":
+	default:
+		return nil, fmt.Errorf("invalid bytes mode %q (available: raw, human, both)", config.Bytes)
+	}
+	switch config.FieldOrder {
+	case "", "sorted", "original":
+	default:
+		return nil, fmt.Errorf("invalid field order %q (available: sorted, original)", config.FieldOrder)
+	}
+	switch config.Output {
+	case "", "text", "json":
+	default:
+		return nil, fmt.Errorf("invalid output format %q (available: text, json)", config.Output)
+	}
+	if config.SkipNonJSON {
+		p.config.NonJSON = "hide"
+	}
+	switch p.config.NonJSON {
+	case "", "plain", "dim", "marker", "hide":
+	default:
+		return nil, fmt.Errorf("invalid non-json mode %q (available: plain, dim, marker, hide)", p.config.NonJSON)
+	}
+	if p.theme, err = NewTheme(config.Theme, colors); err != nil {
+		return nil, err
+	}
+	enabled, err := colorEnabled(config.Color, out)
+	if err != nil {
+		return nil, err
+	}
+	if p.outColor, err = p.openOut(); err != nil {
+		return nil, err
+	}
+	if config.Sink != "" {
+		if p.sink, err = openSink(config.Sink); err != nil {
+			return nil, err
+		}
+	}
+	p.stripColors = !enabled && p.outColor
+	enabled = enabled || p.outColor
+	p.theme.SetColorEnabled(enabled)
+	p.useColor = enabled
+	var w io.Writer = out
+	if p.stripColors {
+		w = stripWriter{w}
+	}
+	p.footer = p.newFooter(w)
+	for _, rule := range config.HighlightRules {
+		r, err := newHighlightRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if r.color != nil && enabled {
+			r.color.EnableColor()
+		} else if r.color != nil {
+			r.color.DisableColor()
+		}
+		p.highlightRules = append(p.highlightRules, r)
+	}
+	for _, s := range config.Highlight {
+		h, err := parseHighlight(s)
+		if err != nil {
+			return nil, err
+		}
+		if h.color == nil {
+			h.color = p.theme.Highlight
+		} else if enabled {
+			h.color.EnableColor()
+		} else {
+			h.color.DisableColor()
+		}
+		p.highlights = append(p.highlights, h)
+	}
+	if config.Format != "" {
+		if p.format, err = p.newFormatTemplate(config.Format); err != nil {
+			return nil, fmt.Errorf("invalid format: %w", err)
+		}
+	}
+	if config.ExecOnMatch != "" {
+		if p.execHook, err = newExecHook(config.ExecOnMatch, config.Exec); err != nil {
+			return nil, err
+		}
+	}
+	if config.Webhook != "" {
+		if p.webhook, err = p.newWebhook(); err != nil {
+			return nil, err
+		}
+	}
+	if config.TransformCmd != "" {
+		p.transformer = newTransformer(config.TransformCmd, config.Workers)
+	}
+	if config.Renderer != "" && (config.Format != "" || config.Output == "json") {
+		return nil, errors.New("--renderer can't be used with --format or --output json")
+	}
+	for _, spec := range config.Parsers {
+		parser, err := p.newParser(spec)
+		if err != nil {
+			return nil, err
+		}
+		p.parsers = append(p.parsers, parser)
+	}
+	if config.Renderer != "" {
+		if p.renderer, err = p.newRenderer(config.Renderer); err != nil {
+			return nil, err
+		}
+	}
+	if config.Script != "" {
+		if p.script, err = loadScript(config.Script); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+type input struct {
+	name   string
+	stream string
+	reader io.Reader
+	// stdin is read on after a signal, until EOF, for the lines logged by
+	// the producer as it stops.
+	stdin bool
+	// interrupt closes the reader on a signal, for the inputs which may
+	// never reach EOF: followed files, streams, URLs and named pipes kept
+	// open. The other ones, like the output of a command, are read until
+	// EOF.
+	interrupt bool
+}
+
+type logEntry struct {
+	source string
+	stream string
+	text   string
+}
+
+// Run pretty prints the logs read from the given files one after another, or
+// from stdin if no files are given ("-" also reads stdin). In follow mode the
+// files are read concurrently until a signal is received, as well as the
+// stream URLs (ws://, wss://, sse+http:// and sse+https://). http:// and
+// https:// URLs are read as files, as their response is received, as well as
+// the objects of storage buckets (eg. s3://bucket/key). With the listen
+// option the logs received on the network are shown instead.
+func (p *PrettyJsonLog) Run(files []string) error {
+	if len(p.config.Listen) > 0 {
+		return p.Listen()
+	}
+	files = append(files, p.config.Inputs...)
+	inputs, err := p.openInputs(files)
+	if err != nil {
+		return err
+	}
+	defer closeInputs(inputs)
+
+	if p.config.Prefix || (len(inputs) > 1 && !p.config.NoPrefix) {
+		p.setSourceLabels(inputs)
+	}
+	concurrent := p.config.Follow
+	for _, in := range inputs {
+		_, stream := in.reader.(*streamReader)
+		concurrent = concurrent || stream
+	}
+	err = p.process(inputs, concurrent, func(os.Signal) {})
+	if err != nil {
+		return err
+	}
+	return p.failOnError()
+}
+
+// process pretty prints the logs read from inputs, one after another or
+// concurrently, until all of them are read. onSignal is called when an
+// interrupt or termination signal is received.
+func (p *PrettyJsonLog) process(inputs []input, concurrent bool, onSignal func(os.Signal)) error {
+	groups := [][]input{inputs}
+	if concurrent {
+		groups = nil
+		for _, in := range inputs {
+			groups = append(groups, []input{in})
+		}
+	}
+	return p.processReader(func(ctx context.Context, q *entryQueue) {
+		wg := sync.WaitGroup{}
+		for _, group := range groups {
+			wg.Add(1)
+			go func(group []input) {
+				defer wg.Done()
+				for _, in := range group {
+					p.readLogs(ctx, in, q)
+				}
+			}(group)
+		}
+		wg.Wait()
+	}, onSignal)
+}
+
+// shutdownTimeout bounds the time spent stopping the readers after a signal,
+// and then showing the entries read.
+const shutdownTimeout = 2 * time.Second
+
+// processReader pretty prints the log entries sent to q by read, until read
+// returns once the inputs are read. When an interrupt or termination signal
+// is received, ctx is canceled for read to stop and onSignal is called.
+func (p *PrettyJsonLog) processReader(read func(ctx context.Context, q *entryQueue), onSignal func(os.Signal)) error {
+	stopCh := make(chan os.Signal, 1)
+	doneCh := make(chan struct{})
+	q := newEntryQueue(p.config.BufferSize, p.config.DropPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		read(ctx, q)
+		close(doneCh)
+	}()
+	defer p.closeSink()
+	defer p.closeExec()
+	defer p.closeWebhook()
+	defer p.closeTransform()
+	defer p.closeScript()
+	defer p.closePlugins()
+
+	if p.config.TUI {
+		return p.runTUI(q.ch, doneCh)
+	}
+
+	var ps *pauser
+	if !p.config.NoPause {
+		ps = startPauser()
+		defer ps.stop()
+	}
+
+	// outErr is the error writing the output, eg. when the reader of the
+	// pipe exited, set when outClosed is closed.
+	var outErr error
+	outClosed := make(chan struct{})
+	var outOnce sync.Once
+	wgPrint := sync.WaitGroup{}
+	wgPrint.Add(1)
+	go func() {
+		defer wgPrint.Done()
+		p.printLogs(q, ps, func(err error) {
+			outOnce.Do(func() {
+				outErr = err
+				close(outClosed)
+			})
+		})
+	}()
+
+	signal.Notify(stopCh,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+	// Writing to a closed pipe returns an error instead of killing the
+	// process, for the terminal and the sink to be restored and closed.
+	pipeCh := make(chan os.Signal, 1)
+	signal.Notify(pipeCh, syscall.SIGPIPE)
+
+	defer signal.Stop(stopCh)
+	defer signal.Stop(pipeCh)
+
+	// After a signal the readers are waited for while they read entries,
+	// until they stop or are idle for shutdownTimeout, and then the entries
+	// read as long to be shown. A second signal exits right away.
+	var deadline <-chan time.Time
+	var sent int64
+	drain := true
+	for done := false; !done; {
+		select {
+		case sig := <-stopCh:
+			if deadline != nil {
+				done, drain = true, false
+				break
+			}
+			cancel()
+			onSignal(sig)
+			sent = q.sent.Load()
+			deadline = time.After(shutdownTimeout)
+		case <-deadline:
+			if n := q.sent.Load(); n != sent {
+				sent = n
+				deadline = time.After(shutdownTimeout)
+				break
+			}
+			// The entries read are shown without waiting for the idle
+			// readers any longer.
+			done = true
+		case <-doneCh:
+			done = true
+		case <-outClosed:
+			// Nothing can be shown anymore, or the --head lines were
+			// shown: the input isn't read further, without waiting for
+			// the readers blocked on it.
+			cancel()
+			onSignal(syscall.SIGPIPE)
+			done, drain = true, false
+		}
+	}
+	q.close()
+	if drain {
+		printed := make(chan struct{})
+		go func() {
+			wgPrint.Wait()
+			close(printed)
+		}()
+		if deadline != nil {
+			select {
+			case <-printed:
+			case <-time.After(shutdownTimeout):
+				log.Printf("the output didn't drain within %s, exiting", shutdownTimeout)
+			}
+		} else {
+			<-printed
+		}
+	}
+	if p.config.Summary {
+		p.printSummary(os.Stderr)
+	}
+	select {
+	case <-outClosed:
+		if !errors.Is(outErr, syscall.EPIPE) {
+			return outErr
+		}
+	default:
+	}
+	return nil
+}
+
+// openInputs opens the files and URLs read by Run. The files, responses and
+// stdin compressed with gzip, bzip2 or zstd are decompressed, except in
+// follow mode.
+func (p *PrettyJsonLog) openInputs(files []string) ([]input, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var inputs []input
+	for _, file := range files {
+		if file == "-" {
+			var r io.Reader = os.Stdin
+			if !p.config.Follow && !p.config.NoExitOnEOF {
+				var err error
+				if r, err = decompress(os.Stdin); err != nil {
+					closeInputs(inputs)
+					return nil, fmt.Errorf("stdin: %w", err)
+				}
+			}
+			inputs = append(inputs, input{name: "stdin", reader: r, stdin: true})
+			continue
+		}
+		var r io.Reader
+		var err error
+		interrupt := true
+		if isStreamURL(file) {
+			r, err = newStreamReader(file)
+		} else if isHTTPURL(file) {
+			r, err = p.openURL(file)
+		} else if isObjectURL(file) {
+			r, err = openObjects(file)
+			interrupt = false
+		} else if p.config.Follow {
+			r, err = newFollowReader(file)
+		} else if p.config.NoExitOnEOF && isFIFO(file) {
+			r, err = openFIFO(file)
+		} else {
+			r, err = openFile(file)
+			interrupt = false
+		}
+		if err != nil {
+			closeInputs(inputs)
+			return nil, err
+		}
+		inputs = append(inputs, input{name: file, reader: r, interrupt: interrupt})
+	}
+	return inputs, nil
+}
+
+// openFile opens a file, decompressing it if needed.
+func openFile(path string) (io.Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &decompressedFile{Reader: r, file: f}, nil
+}
+
+// setSourceLabels sets the labels prefixed to the lines of each input: the
+// file name, aligned and colored differently for each input. The full path
+// is used if the file names are not unique.
+func (p *PrettyJsonLog) setSourceLabels(inputs []input) {
+	names := make([]string, len(inputs))
+	seen := map[string]bool{}
+	unique := true
+	for i, in := range inputs {
+		names[i] = filepath.Base(in.name)
+		unique = unique && !seen[names[i]]
+		seen[names[i]] = true
+	}
+	width := 0
+	for i, in := range inputs {
+		if !unique {
+			names[i] = in.name
+		}
+		width = max(width, ansi.StringWidth(names[i]))
+	}
+	p.labelsMu.Lock()
+	defer p.labelsMu.Unlock()
+	p.sourceLabels = map[string]string{}
+	for i, in := range inputs {
+		if _, ok := p.sourceLabels[in.name]; !ok {
+			pad := strings.Repeat(" ", width-ansi.StringWidth(names[i]))
+			p.sourceLabels[in.name] = p.paint(p.theme.SourceColor(i), names[i]) + pad + " "
+		}
+	}
+}
+
+func closeInputs(inputs []input) {
+	for _, in := range inputs {
+		if c, ok := in.reader.(io.Closer); ok && in.reader != os.Stdin {
+			c.Close()
+		}
+	}
+}
+
+// readLogs sends the lines of an input to q until EOF, or until ctx is
+// canceled for the inputs to interrupt.
+func (p *PrettyJsonLog) readLogs(ctx context.Context, in input, q *entryQueue) {
+	reader := in.reader
+	if in.stdin {
+		if p.config.NoExitOnEOF {
+			reader = &eofWaiter{ctx: ctx, r: reader}
+		}
+	} else if c, ok := reader.(io.Closer); ok && in.interrupt {
+		// The reads blocked on the input are interrupted by closing it.
+		defer context.AfterFunc(ctx, func() { c.Close() })()
+	}
+	r := bufio.NewReader(reader)
+	cri := &criJoiner{}
+	multi := &jsonJoiner{}
+	send := func(lines []string) bool {
+		for _, text := range lines {
+			if strings.TrimSpace(text) != "" && !q.send(logEntry{source: in.name, stream: in.stream, text: text}) {
+				return false
+			}
+		}
+		return true
+	}
+	var buf []byte
+	for {
+		line, truncated, err := readLine(r, buf[:0], p.config.MaxLineSize)
+		if cap(line) <= maxPooledBuffer {
+			buf = line
+		}
+		if truncated {
+			log.Printf("%s: line longer than %d bytes truncated", in.name, p.config.MaxLineSize)
+		}
+		text, complete := cri.join(strings.TrimSuffix(string(line), "\r"))
+		if complete && !send(multi.add(text)) {
+			return
+		}
+		if err != nil {
+			send(multi.flush())
+			if err != io.EOF && (!in.interrupt || ctx.Err() == nil) {
+				log.Println(err)
+			}
+			return
+		}
+	}
+}
+
+// readLine appends a line of any length to line, without the trailing
+// newline, and returns it. If max is positive, only the first max bytes of
+// the line are returned and the rest is discarded.
+func readLine(r *bufio.Reader, line []byte, max int) ([]byte, bool, error) {
+	truncated := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if err == nil {
+			chunk = chunk[:len(chunk)-1]
+		}
+		if max > 0 && len(line)+len(chunk) > max {
+			line = append(line, chunk[:max-len(line)]...)
+			truncated = true
+		} else {
+			line = append(line, chunk...)
+		}
+		if err != bufio.ErrBufferFull {
+			return line, truncated, err
+		}
+	}
+}
+
+// printLogs writes the entries read from q to the output, with a marker
+// where entries were dropped. While the output is paused the formatted lines
+// are kept in memory. The output is buffered while more entries are waiting,
+// and flushed once they are all written or at least every flushInterval.
+// With --tail only the last lines are kept, and written once q is closed.
+// The footer is drawn again after each flush and every footerInterval.
+// closed is called if writing fails, or with a nil error once the --head
+// lines are written, the entries are discarded after that.
+func (p *PrettyJsonLog) printLogs(q *entryQueue, ps *pauser, closed func(error)) {
+	w := bufio.NewWriterSize(p.output(), 64<<10)
+	lastFlush := time.Now()
+	finished := false
+	flushOutput := func() {
+		// The errors of a bufio.Writer are kept, the writes after one do
+		// nothing.
+		if err := w.Flush(); err != nil {
+			closed(err)
+		}
+		lastFlush = time.Now()
+		if !ps.isPaused() && !finished {
+			p.footer.draw()
+		}
+	}
+	defer func() {
+		finished = true
+		flushOutput()
+		p.footer.erase()
+	}()
+	var tick <-chan time.Time
+	if p.footer != nil {
+		ticker := time.NewTicker(footerInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	results := p.formatEntries(q.ch)
+	var pending []string
+	flush := func() {
+		for _, out := range pending {
+			w.WriteString(out)
+		}
+		pending = nil
+	}
+	var tail *tailRing
+	if p.config.Tail > 0 {
+		tail = newTailRing(p.config.Tail)
+	}
+	shown := 0
+	for {
+		select {
+		case entry, ok := <-results:
+			if !ok {
+				if tail != nil {
+					for _, out := range tail.take() {
+						p.stats.addShown()
+						pending = append(pending, out)
+					}
+				}
+				if p.dedupe != nil {
+					pending = append(pending, p.dedupe.flush())
+				}
+				pending = append(pending, p.droppedMarker(q))
+				flush()
+				return
+			}
+			if !entry.ok {
+				continue
+			}
+			out := p.droppedMarker(q) + entry.out
+			if tail != nil {
+				tail.add(out)
+				continue
+			}
+			p.stats.addShown()
+			shown++
+			if ps.isPaused() {
+				pending = append(pending, out)
+			} else {
+				flush()
+				w.WriteString(out)
+			}
+			if p.config.Head > 0 && shown >= p.config.Head {
+				flush()
+				flushOutput()
+				closed(nil)
+				// The entries still read are discarded, for the readers
+				// not to wait for the output.
+				go func() {
+					for range results {
+					}
+				}()
+				return
+			}
+			if !ps.isPaused() && ((len(results) == 0 && len(q.ch) == 0) || time.Since(lastFlush) >= flushInterval) {
+				flushOutput()
+			}
+		case <-ps.resumed():
+			flush()
+			flushOutput()
+		case <-tick:
+			p.footer.update()
+			flushOutput()
+		}
+	}
+}
+
+// formatEntry renders a log entry, including the trailing newline, and returns
+// its normalized level. It returns false if the entry is filtered out.
+func (p *PrettyJsonLog) formatEntry(entry logEntry) (string, string, bool) {
+	// Some producers color their JSON lines, the escape sequences are
+	// stripped before parsing and kept for the lines shown as is.
+	colored := ""
+	if strings.ContainsRune(entry.text, '\x1b') {
+		colored = entry.text
+		entry.text = ansi.Strip(entry.text)
+	}
+	prefix := p.sourceLabel(entry.source)
+	if entry.stream == "stderr" {
+		prefix += p.streamLabel(entry.stream) + " "
+	}
+	cri, isCRI := parseCRI(entry.text)
+	if isCRI {
+		prefix += p.streamLabel(cri.stream) + " "
+		entry.text = cri.text
+	}
+	syslog, isSyslog := parseSyslog(entry.text)
+	if isSyslog {
+		entry.text = syslog.text
+	}
+	if p.config.StripPrefix {
+		var label string
+		label, entry.text = stripPrefix(entry.text)
+		if label != "" && p.config.PrefixLabel {
+			prefix += p.paint(p.theme.Source, label) + " "
+		}
+	}
+	line, err := NewLogLine(entry.text, p)
+	if err != nil {
+		if parts := splitConcatenated(entry.text); parts != nil {
+			return p.formatConcatenated(entry, parts)
+		}
+	}
+	if err == nil {
+		if records, ok := p.otelRecords(entry.text, line); ok {
+			return p.formatConcatenated(entry, records)
+		}
+		if text, ok := p.clefLine(entry.text, line); ok {
+			line, err = NewLogLine(text, p)
+		}
+	}
+	if err != nil && p.config.ExtractJSON {
+		if lead, extracted, ok := p.extractJSON(entry.text); ok {
+			line, err = extracted, nil
+			prefix += p.paint(p.theme.Source, lead) + " "
+		}
+	}
+	if err != nil && len(p.parsers) > 0 {
+		if parsed, ok := p.parse(entry.text); ok {
+			line, err = parsed, nil
+		}
+	}
+	if err != nil && isSyslog {
+		line, err = p.syslogMessage(entry.text)
+	}
+	if err != nil {
+		// log.Println(err)
+		p.stats.addNonJSON()
+		if p.filter != nil || !p.grepMatch(entry.text) || p.config.Output == "json" || p.aggregator != nil {
+			return "", "", false
+		}
+		if p.thinner != nil {
+			keep, marker := p.thinner.keep("")
+			if !keep {
+				return "", "", false
+			}
+			prefix = marker + prefix
+		}
+		if p.dedupe != nil {
+			prefix = p.dedupe.flush() + prefix
+		}
+		if p.gaps != nil {
+			prefix = p.gaps.marker(time.Time{}, false) + prefix
+		}
+		if colored != "" && !isCRI && !p.config.StripPrefix && p.config.NonJSON != "dim" {
+			entry.text = colored
+		}
+		if p.redactor != nil {
+			entry.text = p.redactor.redactText(entry.text)
+		}
+		return p.formatNonJSON(prefix, entry.text)
+	}
+	if isCRI {
+		line.fallbackTime = cri.time
+	}
+	if isSyslog {
+		line.addSyslogHeader(syslog)
+	}
+	return p.formatLine(entry, line, prefix)
+}
+
+// formatLine renders the parsed line of an entry like formatEntry, after the
+// labels of prefix.
+func (p *PrettyJsonLog) formatLine(entry logEntry, line *logLine, prefix string) (string, string, bool) {
+	if p.transformer != nil {
+		var keep bool
+		if line, keep = p.transformer.transform(line); !keep {
+			return "", "", false
+		}
+	}
+	if p.script != nil {
+		var keep bool
+		if line, keep = p.runScript(line); !keep {
+			return "", "", false
+		}
+	}
+	p.stats.addLine(line)
+	p.checkFailOn(line)
+	p.store(entry, line)
+	level, _ := line.level()
+	if !p.levelEnabled(level) {
+		return "", "", false
+	}
+	if !p.since.IsZero() || !p.until.IsZero() {
+		if t, ok := line.parsedTime(); ok && !p.inTimeRange(t) {
+			return "", "", false
+		}
+	}
+	if p.filter != nil && !p.filter.match(line.decoded()) {
+		return "", "", false
+	}
+	if p.grep != nil || p.grepV != nil {
+		grepText := entry.text
+		if !p.config.GrepRaw {
+			grepText, _ = line.message()
+		}
+		if !p.grepMatch(grepText) {
+			return "", "", false
+		}
+	}
+	if p.thinner != nil {
+		keep, marker := p.thinner.keep(level)
+		if !keep {
+			return "", "", false
+		}
+		prefix = marker + prefix
+	}
+	if p.alerter != nil {
+		p.alerter.check(line)
+	}
+	if p.execHook != nil {
+		p.execHook.check(line)
+	}
+	if p.webhook != nil {
+		p.webhook.check(line, entry.source)
+	}
+	if p.aggregator != nil {
+		p.aggregator.add(line)
+		return "", "", false
+	}
+	if p.dedupe != nil {
+		dup, marker := p.dedupe.check(entry.source, line)
+		if dup {
+			return "", "", false
+		}
+		prefix = marker + prefix
+	}
+	if p.gaps != nil {
+		t, ok := line.parsedTime()
+		prefix = p.gaps.marker(t, ok) + prefix
+	}
+	if len(p.highlightRules) > 0 {
+		line.applyHighlightRules()
+	}
+	if p.config.Output == "json" {
+		out, err := json.Marshal(line.line)
+		if err != nil {
+			return "", "", false
+		}
+		return string(out) + "\n", level, true
+	}
+	if p.format != nil {
+		return p.formatTemplate(line, entry, level), level, true
+	}
+	if p.renderer != nil {
+		if out, ok := p.renderRecord(line, entry, level); ok {
+			return prefix + out, level, true
+		}
+	}
+	if p.grouper != nil {
+		prefix += p.grouper.mark(line)
+	}
+	l := line.popLevel()
+	t := line.popTime()
+	c := line.popComponent()
+	m := line.popMessage()
+	fields := line.getFieldList()
+	b := getBuffer()
+	defer putBuffer(b)
+	p.fitLine(b, prefix+t+" "+l+" "+c+m, fields)
+	b.WriteByte('\n')
+	b.WriteString(line.getBlocks())
+	return b.String(), level, true
+}
+
+// formatNonJSON renders a line that couldn't be parsed according to the
+// non-json mode.
+func (p *PrettyJsonLog) formatNonJSON(prefix, text string) (string, string, bool) {
+	switch p.config.NonJSON {
+	case "dim":
+		return prefix + p.highlightMatches(text, p.theme.NonJSON) + "\n", "", true
+	case "marker":
+		return prefix + p.paint(p.theme.NonJSON, "» ") + p.highlightMatches(text, nil) + "\n", "", true
+	case "hide":
+		return "", "", false
+	}
+	return prefix + p.highlightMatches(text, nil) + "\n", "", true
+}
+
+// extractJSON looks for a JSON object at the end of a line with a leading
+// text, like `2024-01-01 app | {"msg": "hello"}`.
+func (p *PrettyJsonLog) extractJSON(text string) (string, *logLine, bool) {
+	for i := strings.IndexByte(text, '{'); i > 0; {
+		if line, err := NewLogLine(text[i:], p); err == nil {
+			return strings.TrimSpace(text[:i]), line, true
+		}
+		next := strings.IndexByte(text[i+1:], '{')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return "", nil, false
+}
+
+type logLine struct {
+	line   map[string]json.RawMessage
+	p      *PrettyJsonLog
+	blocks []multilineBlock
+
+	// keyOrder holds the original order of the keys of the objects by path,
+	// nil to sort them.
+	keyOrder map[string][]string
+
+	// fallbackTime is shown when the line has no time field, e.g. the
+	// timestamp of the CRI log line wrapping it.
+	fallbackTime time.Time
+
+	// highlights holds the colors of the fields highlighted by the highlight
+	// rules, by path.
+	highlights map[string]*color.Color
+}
+
+func NewLogLine(log string, p *PrettyJsonLog) (*logLine, error) {
+	var keyOrder map[string][]string
+	line, err := splitObject([]byte(log))
+	if err != nil {
+		logfmtLine, keys, logfmtErr := parseLogfmt(log)
+		if logfmtErr != nil {
+			return nil, err
+		}
+		line = logfmtLine
+		keyOrder = map[string][]string{"": keys}
+	} else if p.config.FieldOrder == "original" {
+		keyOrder = jsonKeyOrder([]byte(log))
+	}
+	return p.newLogLine(line, keyOrder), nil
+}
+
+// newLogLine returns the line of the given fields, with the original order
+// of their keys by path used with --field-order original.
+func (p *PrettyJsonLog) newLogLine(fields map[string]json.RawMessage, keyOrder map[string][]string) *logLine {
+	if p.config.FieldOrder != "original" {
+		keyOrder = nil
+	}
+	l := &logLine{line: fields, p: p, keyOrder: keyOrder}
+	if len(p.config.Rename) > 0 {
+		l.renameFields()
+	}
+	if p.redactor != nil {
+		p.redactor.redactLine(l.line)
+	}
+	return l
+}
+
+// time returns the parsed time of the line and the key it was found in.
+func (l *logLine) time() (time.Time, string, error) {
+	for _, timeKey := range l.p.timeKeys {
+		ti := l.getInterfaceField(timeKey, "")
+		tstr := ""
+		switch v := ti.(type) {
+		case string:
+			if isEpoch(v) {
+				t, err := parseEpoch(v, l.p.config.EpochUnit)
+				return t, timeKey, err
+			}
+			tstr = v
+		case float64:
+			raw, _ := l.rawField(timeKey)
+			t, err := parseEpoch(string(bytes.TrimSpace(raw)), l.p.config.EpochUnit)
+			return t, timeKey, err
+		case map[string]interface{}:
+			if t, ok := parseTimestampObject(v); ok {
+				return t, timeKey, nil
+			}
+		}
+		if tstr == "" {
+			continue
+		}
+
+		if len(l.p.timeLayouts) > 0 {
+			tp, err := l.p.parseTimeLayouts(tstr)
+			return tp, timeKey, err
+		}
+		// Most times are RFC 3339 in UTC, parsed without guessing the
+		// layout.
+		if strings.HasSuffix(tstr, "Z") {
+			if tp, err := time.Parse(time.RFC3339Nano, tstr); err == nil {
+				return tp, timeKey, nil
+			}
+		}
+		tp, err := dateparse.ParseAny(tstr)
+		return tp, timeKey, err
+	}
+	return time.Time{}, "", nil
+}
+
+// parseTimestampObject parses protobuf style timestamps like
+// {"seconds": 1624829360, "nanos": 868000000} used by Google Cloud logs.
+func parseTimestampObject(v map[string]interface{}) (time.Time, bool) {
+	toInt := func(v interface{}) (int64, bool) {
+		switch v := v.(type) {
+		case float64:
+			return int64(v), true
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			return n, err == nil
+		}
+		return 0, false
+	}
+	seconds, ok := toInt(v["seconds"])
+	if !ok {
+		return time.Time{}, false
+	}
+	nanos, _ := toInt(v["nanos"])
+	return time.Unix(seconds, nanos), true
+}
+
+// parsedTime returns the time of the line, or the fallback time if it has
+// none. It returns false if the line has no valid time.
+func (l *logLine) parsedTime() (time.Time, bool) {
+	t, timeKey, err := l.time()
+	if err != nil {
+		return time.Time{}, false
+	}
+	if timeKey == "" {
+		return l.fallbackTime, !l.fallbackTime.IsZero()
+	}
+	return t, true
+}
+
+func (l *logLine) popTime() string {
+	t, timeKey, err := l.time()
+	if err != nil {
+		return l.p.paint(l.p.theme.Time, fmt.Sprintf("INVALID TIME [%v]", err))
+	}
+	if timeKey == "" {
+		if !l.fallbackTime.IsZero() {
+			return l.p.renderTime(l.fallbackTime)
+		}
+		return l.p.paint(l.p.theme.Time, "EMPTY TIME")
+	}
+	l.deleteField(timeKey)
+	return l.p.renderTime(t)
+}
+
+// message returns the message of the line and the key it was found in.
+func (l *logLine) message() (string, string) {
+	for _, messageKey := range l.p.messageKeys {
+		msg := l.getStringField(messageKey, "")
+		if msg != "" {
+			return msg, messageKey
+		}
+	}
+	return "", ""
+}
+
+func (l *logLine) popMessage() string {
+	msg, messageKey := l.message()
+	if messageKey == "" {
+		return l.p.paint(l.p.theme.Null, "null")
+	}
+	l.deleteField(messageKey)
+	if c, ok := l.highlight(messageKey); ok {
+		return l.p.paint(c, msg)
+	}
+	return l.p.highlightMatches(msg, l.p.theme.Message)
+}
+
+func (p *PrettyJsonLog) normalizeLevel(lv interface{}) string {
+	switch lv := lv.(type) {
+	case float64:
+		level, ok := p.intLevels[int(lv)]
+		if !ok {
+			return fmt.Sprint(lv)
+		}
+		return p.aliasLevel(level)
+	case string:
+		// Some loggers write numeric levels as strings, eg. PRIORITY="6".
+		if n, err := strconv.Atoi(lv); err == nil {
+			if level, ok := p.intLevels[n]; ok {
+				return p.aliasLevel(level)
+			}
+		}
+		return p.aliasLevel(lv)
+	}
+	return fmt.Sprint(lv)
+}
+
+// parseSeverity returns the severity of a level given by name or number.
+func (p *PrettyJsonLog) parseSeverity(s string) (int, error) {
+	level := p.normalizeLevel(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		level = p.normalizeLevel(float64(n))
+	}
+	severity, ok := p.levelSeverity[level]
+	if !ok {
+		return 0, fmt.Errorf("unknown level %q", s)
+	}
+	return severity, nil
+}
+
+func (p *PrettyJsonLog) aliasLevel(level string) string {
+	level = strings.ToUpper(level)
+	if alias, ok := p.levelAliases[level]; ok {
+		return alias
+	}
+	return level
+}
+
+// checkFailOn records whether the line is at or above the --fail-on level.
+func (p *PrettyJsonLog) checkFailOn(line *logLine) {
+	if p.failSeverity == 0 {
+		return
+	}
+	level, _ := line.level()
+	if severity, ok := p.levelSeverity[level]; ok && severity >= p.failSeverity {
+		p.failed.Store(true)
+	}
+}
+
+// failOnError returns an error if a line at or above the --fail-on level was
+// read.
+func (p *PrettyJsonLog) failOnError() error {
+	if p.failed.Load() {
+		return fmt.Errorf("lines at level %s or above were seen", strings.ToUpper(p.config.FailOn))
+	}
+	return nil
+}
+
+// levelEnabled reports whether lines with the given normalized level pass the
+// minimum level. Unknown levels are always shown.
+func (p *PrettyJsonLog) levelEnabled(level string) bool {
+	if p.minSeverity == 0 {
+		return true
+	}
+	severity, ok := p.levelSeverity[level]
+	return !ok || severity >= p.minSeverity
+}
+
+// level returns the normalized level of the line and the key it was found in.
+func (l *logLine) level() (string, string) {
+	for _, levelKey := range l.p.levelKeys {
+		lvl := l.p.normalizeLevel(l.getInterfaceField(levelKey, ""))
+		if lvl != "" {
+			return lvl, levelKey
+		}
+	}
+	return "", ""
+}
+
+func (l *logLine) popLevel() string {
+	level, levelKey := l.level()
+	if levelKey != "" {
+		l.deleteField(levelKey)
+	}
+	c, ok := l.p.theme.Level(level)
+	if hc, hok := l.highlight(levelKey); hok && levelKey != "" {
+		c = hc
+	}
+	if !ok {
+		return l.p.paint(c, level)
+	}
+	if n := utf8.RuneCountInString(level); n < 5 {
+		level = strings.Repeat(" ", 5-n) + level
+	}
+	return l.p.paint(c, level)
+}
+
+func (l *logLine) getFields() string {
+	return strings.Join(l.getFieldList(), " ")
+}
+
+// getFieldList renders the fields of the line as key=value pairs, the
+// priority fields first and then the others sorted by key.
+func (l *logLine) getFieldList() []string {
+	var fields []string
+	for _, k := range l.fieldKeys() {
+		f := l.line[k]
+		vi, err := decodeRaw(f, true)
+		if err != nil {
+			continue
+		}
+		if l.p.config.ParseNestedJSON {
+			vi = parseNestedJSON(vi)
+		}
+		if s, ok := vi.(string); ok && isMultiline(s) {
+			l.addBlock(k, s)
+			continue
+		}
+		if l.p.shouldExpand(vi) {
+			l.blocks = append(l.blocks, multilineBlock{path: k, expanded: vi})
+			fields = append(fields, l.p.paint(l.p.theme.FieldKey, k)+"="+l.p.paint(l.p.theme.Multiline, "↓"))
+			continue
+		}
+		if l.p.config.Flatten {
+			fields = append(fields, l.flattenField(k, 1, vi)...)
+			continue
+		}
+		fields = append(fields, l.p.paint(l.p.theme.FieldKey, k)+"="+l.getFieldValue(k, 1, vi))
+	}
+	return fields
+}
+
+// flattenField renders the values nested in objects as separate fields with
+// dot separated keys, like http.method="GET" http.status=200.
+func (l *logLine) flattenField(path string, depth int, vi interface{}) []string {
+	m, ok := vi.(map[string]interface{})
+	if !ok || len(m) == 0 || (l.p.config.MaxDepth > 0 && depth > l.p.config.MaxDepth) {
+		return []string{l.p.paint(l.p.theme.FieldKey, path) + "=" + l.getFieldValue(path, depth, vi)}
+	}
+	var fields []string
+	for _, k := range l.objectKeys(path, m) {
+		fields = append(fields, l.flattenField(path+"."+k, depth+1, m[k])...)
+	}
+	return fields
+}
+
+// fieldKeys returns the keys of the fields in the order they're shown: the
+// priority fields and then the others in their original order with
+// --field-order original, or sorted.
+func (l *logLine) fieldKeys() []string {
+	position := map[string]int{}
+	for i, k := range l.keyOrder[""] {
+		position[k] = i
+	}
+	keys := make([]string, 0, len(l.line))
+	for k := range l.line {
+		if !l.p.hiddenField(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, iok := l.p.fieldPriority[keys[i]]
+		pj, jok := l.p.fieldPriority[keys[j]]
+		if iok != jok {
+			return iok
+		}
+		if iok {
+			return pi < pj
+		}
+		if l.keyOrder != nil {
+			return position[keys[i]] < position[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// hiddenField tells if a field isn't shown: its key is in the hidden fields,
+// or starts with one of them ending with "*".
+func (p *PrettyJsonLog) hiddenField(key string) bool {
+	for _, hidden := range p.config.HiddenFields {
+		if prefix, ok := strings.CutSuffix(hidden, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if hidden == key {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldValue formats a decoded field value. path is the dot separated
+// path of the value in the line and depth its nesting level, 1 for the top
+// level fields.
+func (l *logLine) getFieldValue(path string, depth int, vi interface{}) string {
+	return l.p.linkTrace(path, vi, l.highlightValue(path, l.fieldValue(path, depth, vi)))
+}
+
+func (l *logLine) fieldValue(path string, depth int, vi interface{}) string {
+	if c := l.p.httpColor(path, vi); c != nil {
+		if s, ok := vi.(string); ok {
+			return l.p.highlightMatches(`"`+l.p.truncateValue(path, s)+`"`, c)
+		}
+		return l.p.highlightMatches(fmt.Sprint(vi), c)
+	}
+	if s, ok := vi.(string); ok {
+		if caller, ok := l.p.formatCaller(path, s); ok {
+			return caller
+		}
+	}
+	switch vi := vi.(type) {
+	case string:
+		if isMultiline(vi) {
+			l.addBlock(path, vi)
+			return l.p.paint(l.p.theme.Multiline, "↓")
+		}
+		return l.p.highlightMatches(`"`+l.p.truncateValue(path, vi)+`"`, l.p.theme.String)
+	case json.Number:
+		if l.p.config.Bytes != "" && l.p.config.Bytes != "raw" && l.p.isByteField(path) {
+			return l.p.highlightMatches(l.p.formatBytes(vi), l.p.theme.Number)
+		}
+		return l.p.highlightMatches(vi.String(), l.p.theme.Number)
+	case bool:
+		return l.p.paint(l.p.theme.Bool, strconv.FormatBool(vi))
+	case map[string]interface{}:
+		c := l.p.theme.Object
+		if l.p.config.MaxDepth > 0 && depth > l.p.config.MaxDepth {
+			return l.p.paint(c, "{…"+plural(len(vi), "key")+"}")
+		}
+		b := getBuffer()
+		defer putBuffer(b)
+		b.WriteString(l.p.paint(c, "{"))
+		for i, k := range l.objectKeys(path, vi) {
+			if i > 0 {
+				b.WriteString(l.p.paint(c, ", "))
+			}
+			b.WriteString(l.p.paint(l.p.theme.FieldKey, k))
+			b.WriteString(l.p.paint(c, ":"))
+			b.WriteString(l.getFieldValue(path+"."+k, depth+1, vi[k]))
+		}
+		b.WriteString(l.p.paint(c, "}"))
+		return b.String()
+	case []interface{}:
+		if l.p.config.MaxDepth > 0 && depth > l.p.config.MaxDepth {
+			return l.p.paint(l.p.theme.Array, "[…"+plural(len(vi), "item")+"]")
+		}
+		c := l.p.theme.Array
+		b := getBuffer()
+		defer putBuffer(b)
+		b.WriteString(l.p.paint(c, "["))
+		for i, v := range vi {
+			if i > 0 {
+				b.WriteString(l.p.paint(c, ", "))
+			}
+			b.WriteString(l.getFieldValue(path, depth+1, v))
+		}
+		b.WriteString(l.p.paint(c, "]"))
+		return b.String()
+	case nil:
+		return l.p.paint(l.p.theme.Null, "null")
+	}
+	return l.p.paint(l.p.theme.Other, fmt.Sprint(vi))
+}
+
+func (l *logLine) getInterfaceField(key string, def interface{}) interface{} {
+	vraw, ok := l.rawField(key)
+	if !ok {
+		return def
+	}
+	vi, err := decodeRaw(vraw, false)
+	if err != nil {
+		return def
+	}
+	// fmt.Println(fmt.Sprintf("%T", vi))
+	return vi
+}
+
+func (l *logLine) getStringField(key string, def string) string {
+	vi := l.getInterfaceField(key, def)
+	v, ok := vi.(string)
+	if !ok {
+		return def // fmt.Sprint(vi)
+	}
+	return v
+}
+
+// truncateValue shortens a string value longer than the max value length of
+// its path, or of its key, with a suffix telling how much was cut.
+func (p *PrettyJsonLog) truncateValue(path, s string) string {
+	limit, ok := p.config.MaxValueLengths[path]
+	if !ok {
+		limit, ok = p.config.MaxValueLengths[path[strings.LastIndex(path, ".")+1:]]
+	}
+	if !ok {
+		limit = p.config.MaxValueLength
+	}
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit]) + fmt.Sprintf("…(+%d chars)", len(runes)-limit)
+}
+
+// plural returns n followed by word, with an s if n is not 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var res []string
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package prettyjsonlog
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+)
+
+func startPTY(cmd *exec.Cmd) (io.ReadCloser, error) {
+	return nil, errors.New("--pty is not supported on this platform")
+}
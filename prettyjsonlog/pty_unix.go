@@ -0,0 +1,48 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package prettyjsonlog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// startPTY starts cmd with its stdout and stderr attached to a new PTY, so
+// that it keeps its colors and progress output, and returns the reader of
+// the PTY.
+func startPTY(cmd *exec.Cmd) (io.ReadCloser, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+	pty.InheritSize(os.Stdout, ptmx)
+
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 1}
+	if err := cmd.Start(); err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+	return ptyReader{ptmx}, nil
+}
+
+// ptyReader reads a PTY, returning io.EOF instead of the EIO error Linux
+// returns once the other end is closed.
+type ptyReader struct {
+	*os.File
+}
+
+func (r ptyReader) Read(b []byte) (int, error) {
+	n, err := r.File.Read(b)
+	if errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
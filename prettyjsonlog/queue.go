@@ -0,0 +1,124 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultBufferSize is the number of entries read ahead of the output by
+// default.
+const defaultBufferSize = 10
+
+// entryQueue buffers the entries read until they are formatted. When it's
+// full, the readers wait with the block policy, the oldest entry is dropped
+// for the new one with drop-oldest, and the new one is dropped with
+// drop-new. Once stopped, the entries are no longer queued.
+type entryQueue struct {
+	ch      chan logEntry
+	policy  string
+	sent    atomic.Int64
+	dropped atomic.Int64
+
+	stopped  chan struct{}
+	stopOnce sync.Once
+	// mu is held by the senders, and by close to wait for them to leave.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newEntryQueue(size int, policy string) *entryQueue {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	return &entryQueue{ch: make(chan logEntry, size), policy: policy, stopped: make(chan struct{})}
+}
+
+func checkDropPolicy(policy string) error {
+	switch policy {
+	case "", "block", "drop-oldest", "drop-new":
+		return nil
+	}
+	return fmt.Errorf("invalid drop policy %q (available: block, drop-oldest, drop-new)", policy)
+}
+
+// send queues an entry, and returns false if the queue is stopped.
+func (q *entryQueue) send(entry logEntry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+	select {
+	case <-q.stopped:
+		return false
+	default:
+	}
+	q.sent.Add(1)
+	switch q.policy {
+	case "drop-new":
+		select {
+		case q.ch <- entry:
+		default:
+			q.dropped.Add(1)
+		}
+	case "drop-oldest":
+		for {
+			select {
+			case q.ch <- entry:
+				return true
+			default:
+			}
+			select {
+			case <-q.ch:
+				q.dropped.Add(1)
+			case <-q.stopped:
+				return false
+			default:
+			}
+		}
+	default:
+		select {
+		case q.ch <- entry:
+		case <-q.stopped:
+			return false
+		}
+	}
+	return true
+}
+
+// stop makes the readers stop sending entries.
+func (q *entryQueue) stop() {
+	q.stopOnce.Do(func() { close(q.stopped) })
+}
+
+// close stops the queue and closes its channel once the senders left, the
+// entries queued before are still received.
+func (q *entryQueue) close() {
+	q.stop()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+}
+
+// takeDropped returns the number of entries dropped since the last call.
+func (q *entryQueue) takeDropped() int64 {
+	return q.dropped.Swap(0)
+}
+
+// droppedMarker returns the line shown in place of the entries dropped
+// since the last call, if any.
+func (p *PrettyJsonLog) droppedMarker(q *entryQueue) string {
+	n := q.takeDropped()
+	if n == 0 {
+		return ""
+	}
+	p.stats.addDropped(n)
+	if p.config.Output == "json" {
+		return ""
+	}
+	return p.paint(p.theme.Time, fmt.Sprintf("  … %d lines dropped, the output was too slow", n)) + "\n"
+}
@@ -0,0 +1,114 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+const redacted = "[REDACTED]"
+
+// builtinRedactKeys and builtinRedactValues are the patterns redacted with
+// --redact: keys of secrets, JWTs, AWS access keys and credit card numbers.
+var (
+	builtinRedactKeys   = []string{`(?i)passw(or)?d|passwd|secret|token|authorization|api[-_]?key|cookie|credential|private[-_]?key`}
+	builtinRedactValues = []string{
+		`eyJ[\w-]+\.eyJ[\w-]+\.[\w-]+`,
+		`\b(AKIA|ASIA)[0-9A-Z]{16}\b`,
+		`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{1,4}\b`,
+	}
+)
+
+// redactor replaces the values of the fields whose key matches one of the
+// key patterns, and the parts of string values matching one of the value
+// patterns, with [REDACTED].
+type redactor struct {
+	keys   []*regexp.Regexp
+	values []*regexp.Regexp
+}
+
+func newRedactor(builtin bool, keys, values []string) (*redactor, error) {
+	if builtin {
+		keys = append(append([]string(nil), builtinRedactKeys...), keys...)
+		values = append(append([]string(nil), builtinRedactValues...), values...)
+	}
+	r := &redactor{}
+	for _, k := range keys {
+		re, err := regexp.Compile(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact key pattern: %w", err)
+		}
+		r.keys = append(r.keys, re)
+	}
+	for _, v := range values {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact value pattern: %w", err)
+		}
+		r.values = append(r.values, re)
+	}
+	return r, nil
+}
+
+func (r *redactor) redactKey(key string) bool {
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactText replaces the parts of s matching the value patterns.
+func (r *redactor) redactText(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// redactLine redacts the fields of a line in place.
+func (r *redactor) redactLine(line map[string]json.RawMessage) {
+	for k, raw := range line {
+		if r.redactKey(k) {
+			line[k], _ = json.Marshal(redacted)
+			continue
+		}
+		vi, err := decodeRaw(raw, true)
+		if err != nil {
+			continue
+		}
+		if v, changed := r.redactValue(vi); changed {
+			if b, err := json.Marshal(v); err == nil {
+				line[k] = b
+			}
+		}
+	}
+}
+
+func (r *redactor) redactValue(vi interface{}) (interface{}, bool) {
+	changed := false
+	switch v := vi.(type) {
+	case string:
+		s := r.redactText(v)
+		return s, s != v
+	case map[string]interface{}:
+		for k, sub := range v {
+			if r.redactKey(k) {
+				v[k] = redacted
+				changed = true
+			} else if sub, ok := r.redactValue(sub); ok {
+				v[k] = sub
+				changed = true
+			}
+		}
+	case []interface{}:
+		for i, sub := range v {
+			if sub, ok := r.redactValue(sub); ok {
+				v[i] = sub
+				changed = true
+			}
+		}
+	}
+	return vi, changed
+}
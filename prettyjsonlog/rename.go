@@ -0,0 +1,98 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// renameFields applies the rename config to the line. A key can be a top
+// level key (including ECS style flat keys like "http.request.method") or the
+// dot separated path of a nested field, resolved like in resolvePath, which
+// is moved to the top level under its new name.
+func (l *logLine) renameFields() {
+	renames := l.p.config.Rename
+	froms := make([]string, 0, len(renames))
+	for from := range renames {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		to := renames[from]
+		path, value, ok := resolvePath(l.line, from)
+		if !ok {
+			continue
+		}
+		if len(path) == 1 {
+			delete(l.line, from)
+			l.line[to] = value
+			l.renameKeyOrder(from, to, true)
+			continue
+		}
+		_, rest, ok := extractPath(l.line[path[0]], path[1:])
+		if !ok {
+			continue
+		}
+		if rest == nil {
+			delete(l.line, path[0])
+		} else {
+			l.line[path[0]] = rest
+		}
+		l.line[to] = value
+		l.renameKeyOrder(path[0], to, rest == nil)
+	}
+}
+
+// renameKeyOrder puts the key to at the position of the key from in the
+// original order of the fields, replacing it if remove is set.
+func (l *logLine) renameKeyOrder(from, to string, remove bool) {
+	if l.keyOrder == nil {
+		return
+	}
+	var keys []string
+	for _, k := range l.keyOrder[""] {
+		if k == from {
+			keys = append(keys, to)
+			if remove {
+				continue
+			}
+		}
+		if k != to {
+			keys = append(keys, k)
+		}
+	}
+	l.keyOrder[""] = keys
+}
+
+// extractPath removes the value at path from the JSON object raw. It returns
+// the value and the rest of the object, nil if it's empty.
+func extractPath(raw json.RawMessage, path []string) (json.RawMessage, json.RawMessage, bool) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, nil, false
+	}
+	value, ok := m[path[0]]
+	if !ok {
+		return nil, nil, false
+	}
+	if len(path) == 1 {
+		delete(m, path[0])
+	} else {
+		var rest json.RawMessage
+		if value, rest, ok = extractPath(value, path[1:]); !ok {
+			return nil, nil, false
+		}
+		if rest == nil {
+			delete(m, path[0])
+		} else {
+			m[path[0]] = rest
+		}
+	}
+	if len(m) == 0 {
+		return value, nil, true
+	}
+	rest, err := json.Marshal(m)
+	if err != nil {
+		return nil, nil, false
+	}
+	return value, rest, true
+}
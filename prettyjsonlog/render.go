@@ -0,0 +1,49 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// maxPooledBuffer is the capacity above which a buffer isn't reused, for a
+// single huge line not to hold its memory for the rest of the run.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool, to be returned with
+// putBuffer once its content is copied.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// paint renders s with the color c like c.Sprint(s), with the escape
+// sequences of c computed the first time only. s is left as is if c is nil.
+// The colors must not be changed once painted with.
+func (p *PrettyJsonLog) paint(c *color.Color, s string) string {
+	if c == nil {
+		return s
+	}
+	seq, ok := p.sequences.Load(c)
+	if !ok {
+		start, end, _ := strings.Cut(c.Sprint("\x00"), "\x00")
+		seq, _ = p.sequences.LoadOrStore(c, [2]string{start, end})
+	}
+	if wrap := seq.([2]string); wrap[0] != "" {
+		return wrap[0] + s + wrap[1]
+	}
+	return s
+}
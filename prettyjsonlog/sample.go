@@ -0,0 +1,91 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// thinner drops lines below WARN to sample them (keeping n out of every m
+// lines) and to limit their rate.
+type thinner struct {
+	p *PrettyJsonLog
+
+	mu         sync.Mutex
+	sampleN    int
+	sampleM    int
+	seen       int
+	rateLimit  int
+	ratePeriod time.Duration
+	windowEnd  time.Time
+	inWindow   int
+	dropped    int
+}
+
+func newThinner(p *PrettyJsonLog, sample, rateLimit string) (*thinner, error) {
+	t := &thinner{p: p}
+	if sample != "" {
+		n, m, ok := strings.Cut(sample, "/")
+		var err1, err2 error
+		t.sampleN, err1 = strconv.Atoi(n)
+		t.sampleM, err2 = strconv.Atoi(m)
+		if !ok || err1 != nil || err2 != nil || t.sampleN <= 0 || t.sampleM < t.sampleN {
+			return nil, fmt.Errorf("invalid sample %q (eg. 1/100)", sample)
+		}
+	}
+	if rateLimit != "" {
+		var err error
+		if t.rateLimit, t.ratePeriod, err = parseRate(rateLimit); err != nil {
+			return nil, fmt.Errorf("invalid rate limit %q (eg. 200/s)", rateLimit)
+		}
+	}
+	return t, nil
+}
+
+// parseRate parses a rate like 200/s, 10/m or 5/h (per second if the unit is
+// omitted).
+func parseRate(s string) (int, time.Duration, error) {
+	n, unit, _ := strings.Cut(s, "/")
+	periods := map[string]time.Duration{"": time.Second, "s": time.Second, "m": time.Minute, "h": time.Hour}
+	count, err := strconv.Atoi(n)
+	period, ok := periods[unit]
+	if err != nil || !ok || count <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q", s)
+	}
+	return count, period, nil
+}
+
+// keep reports whether a line with the given level is shown. When a new rate
+// limit period starts, it also returns a marker with the number of lines
+// dropped in the previous one.
+func (t *thinner) keep(level string) (bool, string) {
+	if severity, ok := t.p.levelSeverity[level]; ok && severity >= t.p.levelSeverity["WARN"] {
+		return true, ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sampleM > 0 {
+		t.seen++
+		if (t.seen-1)%t.sampleM >= t.sampleN {
+			return false, ""
+		}
+	}
+	if t.rateLimit == 0 {
+		return true, ""
+	}
+	marker := ""
+	if now := time.Now(); now.After(t.windowEnd) {
+		if t.dropped > 0 && t.p.config.Output != "json" {
+			marker = t.p.paint(t.p.theme.Time, fmt.Sprintf("  … %d lines dropped by the rate limit", t.dropped)) + "\n"
+		}
+		t.windowEnd, t.inWindow, t.dropped = now.Add(t.ratePeriod), 0, 0
+	}
+	if t.inWindow >= t.rateLimit {
+		t.dropped++
+		return false, ""
+	}
+	t.inWindow++
+	return true, marker
+}
@@ -0,0 +1,110 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Script changes the fields of the lines before they're shown, eg. a Lua
+// script defining an on_line function.
+type Script interface {
+	// OnLine returns the fields replacing the decoded fields of a line, and
+	// false to drop the line. It may be called concurrently.
+	OnLine(fields map[string]interface{}) (map[string]interface{}, bool, error)
+	Close() error
+}
+
+// scriptEngines load the scripts of the --script option by file extension.
+var scriptEngines = map[string]func(path string) (Script, error){}
+
+// RegisterScriptEngine registers the engine loading the scripts of the
+// script option with the given file extension (eg. ".lua"). It must be
+// called before NewPrettyJsonLog, usually from an init function.
+func RegisterScriptEngine(ext string, load func(path string) (Script, error)) {
+	scriptEngines[ext] = load
+}
+
+func loadScript(path string) (Script, error) {
+	load, ok := scriptEngines[filepath.Ext(path)]
+	if !ok {
+		var exts []string
+		for ext := range scriptEngines {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		return nil, fmt.Errorf("invalid script %q (available extensions: %s)", path, strings.Join(exts, ", "))
+	}
+	return load(path)
+}
+
+// runScript returns the line with the fields returned by the script, and
+// false if the script drops it. The line is kept as is if the script fails.
+func (p *PrettyJsonLog) runScript(line *logLine) (*logLine, bool) {
+	fields, keep, err := p.script.OnLine(line.decoded())
+	if err == nil && !keep {
+		return nil, false
+	}
+	var data []byte
+	if err == nil {
+		data, err = encodeScripted(line, fields)
+	}
+	var scripted *logLine
+	if err == nil {
+		scripted, err = NewLogLine(string(data), p)
+	}
+	if err != nil {
+		p.scriptErr.Do(func() { log.Printf("script: %v", err) })
+		return line, true
+	}
+	scripted.fallbackTime = line.fallbackTime
+	return scripted, true
+}
+
+// encodeScripted returns the fields returned by a script as a JSON object.
+// The fields the script didn't change are copied as written in the line, the
+// numbers given to the script as float64 would lose the digits of the large
+// integers like IDs.
+func encodeScripted(line *logLine, fields map[string]interface{}) ([]byte, error) {
+	original := line.decoded()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		raw, ok := line.line[k]
+		if v, had := original[k]; !had || !ok || !reflect.DeepEqual(v, fields[k]) {
+			var err error
+			if raw, err = json.Marshal(fields[k]); err != nil {
+				return nil, err
+			}
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(raw)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// closeScript releases the script, if any.
+func (p *PrettyJsonLog) closeScript() {
+	if p.script == nil {
+		return
+	}
+	if err := p.script.Close(); err != nil {
+		log.Printf("script: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SinkEntry is a parsed line stored by a sink.
+type SinkEntry struct {
+	// Time is zero if the line has no time.
+	Time    time.Time
+	Level   string
+	Message string
+	Source  string
+	// Line is the line as compact JSON, with the fields renamed and redacted.
+	Line string
+}
+
+// Sink stores the parsed lines while they are shown, eg. in a database to
+// query them later.
+type Sink interface {
+	Store(entry SinkEntry) error
+	Close() error
+}
+
+// sinks open the sinks of the --sink option by kind.
+var sinks = map[string]func(path string) (Sink, error){}
+
+// RegisterSink registers a kind of sink of the sink option, given as
+// kind:path (eg. sqlite:session.db). It must be called before
+// NewPrettyJsonLog, usually from an init function.
+func RegisterSink(kind string, open func(path string) (Sink, error)) {
+	sinks[kind] = open
+}
+
+func openSink(spec string) (Sink, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	open, ok := sinks[kind]
+	if !ok || path == "" {
+		var kinds []string
+		for kind := range sinks {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		return nil, fmt.Errorf("invalid sink %q (available: %s, eg. sqlite:session.db)", spec, strings.Join(kinds, ", "))
+	}
+	return open(path)
+}
+
+// store stores a parsed line in the sink, if any. The first error is
+// logged, the lines are still shown.
+func (p *PrettyJsonLog) store(entry logEntry, line *logLine) {
+	if p.sink == nil {
+		return
+	}
+	data, err := json.Marshal(line.line)
+	if err == nil {
+		t, _ := line.parsedTime()
+		level, _ := line.level()
+		msg, _ := line.message()
+		err = p.sink.Store(SinkEntry{Time: t, Level: level, Message: msg, Source: entry.source, Line: string(data)})
+	}
+	if err != nil {
+		p.sinkErr.Do(func() { log.Printf("sink: %v", err) })
+	}
+}
+
+// closeSink writes the lines left in the sink, if any.
+func (p *PrettyJsonLog) closeSink() {
+	if p.sink == nil {
+		return
+	}
+	if err := p.sink.Close(); err != nil {
+		log.Printf("sink: %v", err)
+	}
+}
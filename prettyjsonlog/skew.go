@@ -0,0 +1,30 @@
+package prettyjsonlog
+
+import (
+	"sync"
+	"time"
+)
+
+// skewDetector finds the times off by more than the --warn-skew threshold:
+// earlier than the time of the previous line, like the lines of a host
+// whose clock is late when merging the logs of several hosts, or in the
+// future.
+type skewDetector struct {
+	threshold time.Duration
+
+	mu   sync.Mutex
+	prev time.Time
+}
+
+// skewed reports whether the time of a line is out of order or in the
+// future.
+func (s *skewDetector) skewed(t time.Time) bool {
+	s.mu.Lock()
+	prev := s.prev
+	s.prev = t
+	s.mu.Unlock()
+	if t.Sub(time.Now()) > s.threshold {
+		return true
+	}
+	return !prev.IsZero() && prev.Sub(t) > s.threshold
+}
@@ -0,0 +1,209 @@
+package prettyjsonlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlogHandler is a slog.Handler that writes records in the same format as
+// the pretty printed JSON logs.
+type SlogHandler struct {
+	p     *PrettyJsonLog
+	level slog.Leveler
+
+	mu     *sync.Mutex
+	attrs  map[string]interface{}
+	groups []string
+}
+
+// NewSlogHandler creates a slog.Handler writing to out. opts may be nil, only
+// opts.Level is used.
+func NewSlogHandler(out io.Writer, config PrettyJsonLogConfig, opts *slog.HandlerOptions) (*SlogHandler, error) {
+	p, err := newPrettyJsonLog(config, out)
+	if err != nil {
+		return nil, err
+	}
+	h := &SlogHandler{p: p, level: slog.LevelInfo, mu: &sync.Mutex{}, attrs: map[string]interface{}{}}
+	if opts != nil && opts.Level != nil {
+		h.level = opts.Level
+	}
+	return h, nil
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle renders a record as a parsed line, without going through its JSON
+// encoding: the time, level and message at the first keys of the config,
+// then the attributes sorted by key.
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := copyAttrs(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, h.groups, a)
+		return true
+	})
+
+	firstKey := func(keys string) string {
+		return strings.Split(keys, ",")[0]
+	}
+	fields := make(map[string]json.RawMessage, len(attrs)+3)
+	var keys []string
+	set := func(key string, v interface{}) error {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, ok := fields[key]; !ok {
+			keys = append(keys, key)
+		}
+		fields[key] = raw
+		return nil
+	}
+	if !r.Time.IsZero() {
+		set(firstKey(h.p.config.TimeFieldKey), r.Time.Format(time.RFC3339Nano))
+	}
+	set(firstKey(h.p.config.LevelFieldKey), slogLevelName(r.Level))
+	set(firstKey(h.p.config.MessageFieldKey), r.Message)
+	attrKeys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		attrKeys = append(attrKeys, k)
+	}
+	sort.Strings(attrKeys)
+	for _, k := range attrKeys {
+		if _, ok := fields[k]; ok {
+			continue
+		}
+		if err := set(k, attrs[k]); err != nil {
+			return err
+		}
+	}
+	line := h.p.newLogLine(fields, map[string][]string{"": keys})
+	entry := logEntry{}
+	if h.p.config.GrepRaw || h.p.format != nil {
+		// The JSON encoding stands for the raw line.
+		if text, err := json.Marshal(line.line); err == nil {
+			entry.text = string(text)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out, _, ok := h.p.formatLine(entry, line, h.p.sourceLabel(""))
+	if !ok {
+		return nil
+	}
+	_, err := io.WriteString(h.p.out, out)
+	return err
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = copyAttrs(h.attrs)
+	for _, a := range attrs {
+		addAttr(h2.attrs, h.groups, a)
+	}
+	return &h2
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(append([]string(nil), h.groups...), name)
+	return &h2
+}
+
+func slogLevelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelDebug:
+		return "trace"
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	}
+	return "error"
+}
+
+// groupMap returns the map of the nested group in m, creating it if needed.
+// It's only called to add an attribute, the groups without attributes are
+// omitted.
+func groupMap(m map[string]interface{}, groups []string) map[string]interface{} {
+	for _, g := range groups {
+		sub, ok := m[g].(map[string]interface{})
+		if !ok {
+			sub = map[string]interface{}{}
+			m[g] = sub
+		}
+		m = sub
+	}
+	return m
+}
+
+func copyAttrs(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[string]interface{}); ok {
+			v = copyAttrs(sub)
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// addAttr adds an attribute to the nested group of m given by groups. The
+// groups are created as attributes are added to them.
+func addAttr(m map[string]interface{}, groups []string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range v.Group() {
+			addAttr(m, groups, ga)
+		}
+		return
+	}
+	m = groupMap(m, groups)
+	switch v.Kind() {
+	case slog.KindString:
+		m[a.Key] = v.String()
+	case slog.KindInt64:
+		m[a.Key] = v.Int64()
+	case slog.KindUint64:
+		m[a.Key] = v.Uint64()
+	case slog.KindFloat64:
+		m[a.Key] = v.Float64()
+	case slog.KindBool:
+		m[a.Key] = v.Bool()
+	case slog.KindDuration:
+		m[a.Key] = v.Duration().String()
+	case slog.KindTime:
+		m[a.Key] = v.Time().Format(time.RFC3339Nano)
+	default:
+		val := v.Any()
+		if err, ok := val.(error); ok {
+			m[a.Key] = err.Error()
+			return
+		}
+		if _, err := json.Marshal(val); err != nil {
+			m[a.Key] = fmt.Sprint(val)
+			return
+		}
+		m[a.Key] = val
+	}
+}
@@ -0,0 +1,73 @@
+package prettyjsonlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Source is a stream of log lines read by RunSources, like the logs of a
+// container.
+type Source struct {
+	// Name labels the lines of the source, unless empty.
+	Name string
+	// Stream is the output stream of the source, the lines of stderr are
+	// labeled.
+	Stream string
+	Reader io.Reader
+}
+
+// RunSources pretty prints the logs of the sources sent on sources, read
+// concurrently as they come and labeled with their name, until sources is
+// closed and all of them are read. stop is called when an interrupt or
+// termination signal is received, it should close sources and end the
+// readers.
+func (p *PrettyJsonLog) RunSources(sources <-chan Source, stop func()) error {
+	err := p.processReader(func(ctx context.Context, q *entryQueue) {
+		wg := sync.WaitGroup{}
+		for src := range sources {
+			if src.Name != "" && !p.config.NoPrefix {
+				p.addSourceLabel(src.Name)
+			}
+			wg.Add(1)
+			go func(in input) {
+				defer wg.Done()
+				p.readLogs(ctx, in, q)
+			}(input{name: src.Name, stream: src.Stream, reader: src.Reader})
+		}
+		wg.Wait()
+	}, func(os.Signal) {
+		stop()
+	})
+	if err != nil {
+		return err
+	}
+	return p.failOnError()
+}
+
+// addSourceLabel adds the label of a source started after the others, padded
+// to the width of the labels so far.
+func (p *PrettyJsonLog) addSourceLabel(name string) {
+	p.labelsMu.Lock()
+	defer p.labelsMu.Unlock()
+	if p.sourceLabels == nil {
+		p.sourceLabels = map[string]string{}
+	}
+	if _, ok := p.sourceLabels[name]; ok {
+		return
+	}
+	p.labelWidth = max(p.labelWidth, ansi.StringWidth(name))
+	pad := strings.Repeat(" ", p.labelWidth-ansi.StringWidth(name))
+	p.sourceLabels[name] = p.paint(p.theme.SourceColor(len(p.sourceLabels)), name) + pad + " "
+}
+
+// sourceLabel returns the label prefixed to the lines of a source.
+func (p *PrettyJsonLog) sourceLabel(name string) string {
+	p.labelsMu.RLock()
+	defer p.labelsMu.RUnlock()
+	return p.sourceLabels[name]
+}
@@ -0,0 +1,115 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stats counts the lines read for the summary printed on exit and the
+// footer. Its methods do nothing on a nil *stats.
+type stats struct {
+	mu      sync.Mutex
+	start   time.Time
+	lines   int
+	shown   int
+	nonJSON int
+	dropped int64
+	levels  map[string]int
+	first   time.Time
+	last    time.Time
+}
+
+func newStats() *stats {
+	return &stats{start: time.Now(), levels: map[string]int{}}
+}
+
+func (s *stats) addLine(line *logLine) {
+	if s == nil {
+		return
+	}
+	level, _ := line.level()
+	t, hasTime := line.parsedTime()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines++
+	s.levels[level]++
+	if hasTime {
+		if s.first.IsZero() || t.Before(s.first) {
+			s.first = t
+		}
+		if t.After(s.last) {
+			s.last = t
+		}
+	}
+}
+
+func (s *stats) addNonJSON() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines++
+	s.nonJSON++
+}
+
+func (s *stats) addDropped(n int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped += n
+}
+
+func (s *stats) addShown() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shown++
+}
+
+// printSummary writes the line counts, per level, the time range of the lines
+// and the time spent reading them.
+func (p *PrettyJsonLog) printSummary(w io.Writer) {
+	s := p.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels := make([]string, 0, len(s.levels))
+	for level := range s.levels {
+		levels = append(levels, level)
+	}
+	p.sortLevels(levels)
+	var counts []string
+	for _, level := range levels {
+		name := level
+		if name == "" {
+			name = "NO LEVEL"
+		}
+		counts = append(counts, fmt.Sprintf("%s %d", name, s.levels[level]))
+	}
+
+	fmt.Fprintln(w, "--- summary ---")
+	fmt.Fprintf(w, "lines:  %d (%d shown, %d not parsed)\n", s.lines, s.shown, s.nonJSON)
+	if s.dropped > 0 {
+		fmt.Fprintf(w, "dropped: %d (--drop-policy %s)\n", s.dropped, p.config.DropPolicy)
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(w, "levels: %s\n", strings.Join(counts, ", "))
+	}
+	if !s.first.IsZero() {
+		loc := p.location
+		if loc == nil {
+			loc = time.Local
+		}
+		const layout = "2006-01-02 15:04:05.000"
+		fmt.Fprintf(w, "time:   %s - %s (%s)\n", s.first.In(loc).Format(layout), s.last.In(loc).Format(layout), s.last.Sub(s.first))
+	}
+	fmt.Fprintf(w, "took:   %s\n", time.Since(s.start).Round(time.Millisecond))
+}
@@ -0,0 +1,185 @@
+package prettyjsonlog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// StreamConnect connects to a stream and writes the lines received to w
+// until the connection ends or ctx is canceled. received tells if something
+// was received, to reset the reconnection backoff.
+type StreamConnect func(ctx context.Context, w io.Writer) (received bool, err error)
+
+// streamSchemes are the schemes of the URLs read as streams: WebSocket
+// messages, and Server-Sent Events with sse+http:// or sse+https://.
+var streamSchemes = map[string]func(u *url.URL) (StreamConnect, error){
+	"ws":        websocketConnect,
+	"wss":       websocketConnect,
+	"sse+http":  sseConnect,
+	"sse+https": sseConnect,
+}
+
+// RegisterStream registers the scheme of the input URLs read as streams with
+// the function returned by open, reconnected with backoff when it returns. It
+// must be called before Run, usually from an init function.
+func RegisterStream(scheme string, open func(u *url.URL) (StreamConnect, error)) {
+	streamSchemes[scheme] = open
+}
+
+// isStreamURL tells if an input is the URL of a stream.
+func isStreamURL(name string) bool {
+	scheme, _, ok := strings.Cut(name, "://")
+	return ok && streamSchemes[scheme] != nil
+}
+
+// streamReader reads the lines received from a stream URL, reconnecting with
+// backoff when the connection is lost, until it's closed.
+type streamReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func newStreamReader(name string) (*streamReader, error) {
+	u, err := url.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	connect, err := streamSchemes[u.Scheme](u)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r, w := io.Pipe()
+	go func() {
+		defer w.Close()
+		delay := minReconnectDelay
+		for {
+			received, err := connect(ctx, w)
+			if ctx.Err() != nil {
+				return
+			}
+			if received {
+				delay = minReconnectDelay
+			}
+			log.Printf("%s: %v, reconnecting in %s", name, err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(2*delay, maxReconnectDelay)
+		}
+	}()
+	return &streamReader{PipeReader: r, cancel: cancel}, nil
+}
+
+// Close disconnects the stream, the lines received so far are read before
+// the end of the stream.
+func (s *streamReader) Close() error {
+	s.cancel()
+	return nil
+}
+
+// websocketConnect receives the messages of a WebSocket, each one holding
+// one or more lines.
+func websocketConnect(u *url.URL) (StreamConnect, error) {
+	origin := &url.URL{Scheme: "http", Host: u.Host}
+	if u.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+	config, err := websocket.NewConfig(u.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, w io.Writer) (bool, error) {
+		ws, err := config.DialContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer ws.Close()
+		stop := context.AfterFunc(ctx, func() { ws.Close() })
+		defer stop()
+		received := false
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				if err == io.EOF {
+					err = fmt.Errorf("connection closed")
+				}
+				return received, err
+			}
+			received = true
+			if _, err := io.WriteString(w, strings.TrimRight(msg, "\r\n")+"\n"); err != nil {
+				return received, err
+			}
+		}
+	}, nil
+}
+
+// sseConnect receives Server-Sent Events, writing the data of each one,
+// and resumes from the last event ID received when reconnecting.
+func sseConnect(u *url.URL) (StreamConnect, error) {
+	target := strings.TrimPrefix(u.String(), "sse+")
+	lastID := ""
+	return func(ctx context.Context, w io.Writer) (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		received := false
+		var data []string
+		s := bufio.NewScanner(resp.Body)
+		s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for s.Scan() {
+			line := s.Text()
+			if line == "" {
+				if len(data) > 0 {
+					received = true
+					if _, err := io.WriteString(w, strings.Join(data, "\n")+"\n"); err != nil {
+						return received, err
+					}
+				}
+				data = nil
+				continue
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data = append(data, value)
+			case "id":
+				lastID = value
+			}
+		}
+		if err := s.Err(); err != nil {
+			return received, err
+		}
+		return received, fmt.Errorf("connection closed")
+	}, nil
+}
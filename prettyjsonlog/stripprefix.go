@@ -0,0 +1,37 @@
+package prettyjsonlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// composePrefixRe matches the `service_1  | ` prefix added by docker-compose.
+	composePrefixRe = regexp.MustCompile(`^([\w.-]+)\s*\| ?`)
+	// kubectlPrefixRe matches the `[pod/name/container] ` prefix added by
+	// kubectl logs --prefix.
+	kubectlPrefixRe = regexp.MustCompile(`^\[([\w.-]+/[\w.-]+(?:/[\w.-]+)?)\] `)
+	// dockerTimestampRe matches the RFC3339 timestamp added by docker logs
+	// --timestamps.
+	dockerTimestampRe = regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d) `)
+)
+
+// stripPrefix removes the prefixes added by docker-compose, kubectl and
+// docker from text. It returns the service or pod name found (if any) and
+// the rest of the line.
+func stripPrefix(text string) (string, string) {
+	var labels []string
+	for {
+		if m := composePrefixRe.FindStringSubmatch(text); m != nil {
+			labels = append(labels, m[1])
+			text = text[len(m[0]):]
+		} else if m := kubectlPrefixRe.FindStringSubmatch(text); m != nil {
+			labels = append(labels, m[1])
+			text = text[len(m[0]):]
+		} else if m := dockerTimestampRe.FindString(text); m != "" {
+			text = text[len(m):]
+		} else {
+			return strings.Join(labels, " "), text
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslog5424Re matches an RFC 5424 syslog message:
+// <PRI>1 TIMESTAMP HOST APP PROCID MSGID STRUCTURED-DATA MSG.
+var syslog5424Re = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+) ?(.*)$`)
+
+// syslog3164Re matches an RFC 3164 syslog message:
+// <PRI>Mmm dd hh:mm:ss HOST TAG: MSG, where TAG is APP or APP[PID]. The
+// RFC 3339 timestamps written by rsyslog are accepted too.
+var syslog3164Re = regexp.MustCompile(`^<(\d{1,3})>(\w{3} [ \d]\d \d\d:\d\d:\d\d|\d{4}-\d\d-\d\dT\S+) (\S+) ([^:\[\s]+)(?:\[(\w+)\])?: ?(.*)$`)
+
+// syslogSDParamRe matches a parameter of a structured data element:
+// NAME="VALUE" where ", \ and ] are escaped with a backslash.
+var syslogSDParamRe = regexp.MustCompile(`^ ([^= \]"]+)="((?:[^"\\]|\\.)*)"`)
+
+// octetCountRe matches the length prefixed to the syslog messages sent over
+// TCP with octet counting framing.
+var octetCountRe = regexp.MustCompile(`^\d+ <`)
+
+// syslogSeverityLevels are the levels shown for the severities of the PRI.
+var syslogSeverityLevels = [8]string{"panic", "fatal", "fatal", "error", "warn", "info", "info", "debug"}
+
+type syslogLine struct {
+	time  time.Time
+	level string
+	// fields are the header fields of the message: host, app, pid, msgid and
+	// the structured data in sd, without the nil values.
+	fields map[string]interface{}
+	text   string
+}
+
+// parseSyslog parses a syslog message, RFC 5424 or RFC 3164, whatever its
+// message is.
+func parseSyslog(text string) (syslogLine, bool) {
+	if !strings.HasPrefix(text, "<") {
+		return syslogLine{}, false
+	}
+	fields := map[string]interface{}{}
+	set := func(key, value string) {
+		if value != "" && value != "-" {
+			fields[key] = value
+		}
+	}
+	var l syslogLine
+	var pri string
+	if m := syslog5424Re.FindStringSubmatch(text); m != nil {
+		pri = m[1]
+		if m[2] != "-" {
+			t, err := time.Parse(time.RFC3339Nano, m[2])
+			if err != nil {
+				return syslogLine{}, false
+			}
+			l.time = t
+		}
+		set("host", m[3])
+		set("app", m[4])
+		set("pid", m[5])
+		set("msgid", m[6])
+		if m[7] != "-" {
+			sd, ok := parseStructuredData(m[7])
+			if !ok {
+				return syslogLine{}, false
+			}
+			fields["sd"] = sd
+		}
+		l.text = strings.TrimPrefix(m[8], "\ufeff")
+	} else if m := syslog3164Re.FindStringSubmatch(text); m != nil {
+		pri = m[1]
+		t, ok := parseSyslogTime(m[2])
+		if !ok {
+			return syslogLine{}, false
+		}
+		l.time = t
+		set("host", m[3])
+		set("app", m[4])
+		set("pid", m[5])
+		l.text = m[6]
+	} else {
+		return syslogLine{}, false
+	}
+	prival, err := strconv.Atoi(pri)
+	if err != nil || prival > 191 {
+		return syslogLine{}, false
+	}
+	l.level = syslogSeverityLevels[prival%8]
+	l.fields = fields
+	return l, true
+}
+
+// parseSyslogTime parses the timestamp of an RFC 3164 message. It has no
+// year, the one putting it closest to now in the past is used.
+func parseSyslogTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("Jan _2 15:04:05", s, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// parseStructuredData parses the structured data elements of an RFC 5424
+// message, like [id name="value"][id2 ...], into objects by element id.
+func parseStructuredData(s string) (map[string]interface{}, bool) {
+	sd := map[string]interface{}{}
+	for s != "" {
+		end := strings.IndexAny(s, " ]")
+		if s[0] != '[' || end < 0 {
+			return nil, false
+		}
+		params := map[string]interface{}{}
+		sd[s[1:end]] = params
+		s = s[end:]
+		for {
+			m := syslogSDParamRe.FindStringSubmatch(s)
+			if m == nil {
+				break
+			}
+			params[m[1]] = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`).Replace(m[2])
+			s = s[len(m[0]):]
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, false
+		}
+		s = s[1:]
+	}
+	return sd, true
+}
+
+// syslogMessage returns a line with the message of a syslog line which isn't
+// JSON nor logfmt.
+func (p *PrettyJsonLog) syslogMessage(text string) (*logLine, error) {
+	data, err := json.Marshal(map[string]string{strings.Split(p.config.MessageFieldKey, ",")[0]: text})
+	if err != nil {
+		return nil, err
+	}
+	return NewLogLine(string(data), p)
+}
+
+// addSyslogHeader adds the time, level and header fields of a syslog line to
+// the fields of its message, which take precedence.
+func (l *logLine) addSyslogHeader(s syslogLine) {
+	if _, timeKey, _ := l.time(); timeKey == "" {
+		l.fallbackTime = s.time
+	}
+	if _, levelKey := l.level(); levelKey == "" {
+		l.setField(strings.Split(l.p.config.LevelFieldKey, ",")[0], s.level)
+	}
+	for _, key := range sortedKeys(s.fields) {
+		if _, ok := l.rawField(key); !ok {
+			l.setField(key, s.fields[key])
+		}
+	}
+}
+
+// setField sets a field of the line, shown after the others with
+// --field-order original.
+func (l *logLine) setField(key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	l.line[key] = data
+	if l.keyOrder != nil {
+		l.keyOrder[""] = append(l.keyOrder[""], key)
+	}
+}
+
+// stripOctetCount removes the length prefixed to the syslog messages sent
+// over TCP with octet counting framing.
+func stripOctetCount(line string) string {
+	if octetCountRe.MatchString(line) {
+		return line[strings.IndexByte(line, ' ')+1:]
+	}
+	return line
+}
@@ -0,0 +1,74 @@
+package prettyjsonlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// newFormatTemplate parses a --format template. Besides the functions of
+// text/template, it can use color (eg. {{color "hi-red bold" .msg}}), json
+// and default (eg. {{default "-" .fields.user}}).
+func (p *PrettyJsonLog) newFormatTemplate(text string) (*template.Template, error) {
+	return template.New("format").Funcs(template.FuncMap{
+		"color": func(spec string, v interface{}) (string, error) {
+			c, err := parseColor(spec)
+			if err != nil {
+				return "", err
+			}
+			if c == nil {
+				return fmt.Sprint(v), nil
+			}
+			if !p.useColor {
+				c.DisableColor()
+			} else {
+				c.EnableColor()
+			}
+			return c.Sprint(v), nil
+		},
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+	}).Parse(text)
+}
+
+// formatTemplate renders a line with the --format template. The template is
+// given the time (formatted with --time-format), level, msg, the remaining
+// fields as a map and already rendered (rest), the raw line and its source.
+func (p *PrettyJsonLog) formatTemplate(line *logLine, entry logEntry, level string) string {
+	data := map[string]interface{}{
+		"level":  level,
+		"raw":    entry.text,
+		"source": entry.source,
+	}
+	if _, levelKey := line.level(); levelKey != "" {
+		line.deleteField(levelKey)
+	}
+	if t, ok := line.parsedTime(); ok {
+		data["time"] = p.formatTime(t)
+	}
+	if _, timeKey, _ := line.time(); timeKey != "" {
+		line.deleteField(timeKey)
+	}
+	msg, msgKey := line.message()
+	data["msg"] = msg
+	if msgKey != "" {
+		line.deleteField(msgKey)
+	}
+	data["fields"] = line.decodedNumbers()
+	data["rest"] = line.getFields()
+
+	var sb strings.Builder
+	if err := p.format.Execute(&sb, data); err != nil {
+		return fmt.Sprintf("format error: %v\n", err)
+	}
+	return strings.TrimSuffix(sb.String(), "\n") + "\n"
+}
@@ -0,0 +1,457 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// Theme holds the colors used to render log lines.
+type Theme struct {
+	Time       *color.Color
+	Message    *color.Color
+	FieldKey   *color.Color
+	Source     *color.Color
+	String     *color.Color
+	Number     *color.Color
+	Bool       *color.Color
+	Null       *color.Color
+	Object     *color.Color
+	Array      *color.Color
+	Other      *color.Color
+	Multiline  *color.Color
+	Frame      *color.Color
+	NonJSON    *color.Color
+	Stderr     *color.Color
+	SlowDelta  *color.Color
+	SkewedTime *color.Color
+	Highlight  *color.Color
+	LineNumber *color.Color
+
+	// SourcePalette holds the colors given to the source labels when reading
+	// multiple inputs. Source is used if it's empty.
+	SourcePalette []*color.Color
+
+	// Levels holds the color of each normalized level. DEFAULT is used for
+	// unknown levels.
+	Levels map[string]*color.Color
+
+	// HTTP holds the colors of the fields of HTTP access logs: status codes
+	// by class (http-2xx), methods (http-get, http-method for the others),
+	// paths (http-path) and client addresses (http-addr).
+	HTTP map[string]*color.Color
+}
+
+// builtinThemes maps theme names to the color spec of each element. Keys that
+// are not elements of the theme are level names.
+var builtinThemes = map[string]map[string]string{
+	"dark": {
+		"time":           "hi-black bold",
+		"message":        "hi-white bold",
+		"field-key":      "hi-black",
+		"source":         "hi-magenta",
+		"string":         "hi-blue",
+		"number":         "hi-cyan",
+		"bool":           "hi-green",
+		"null":           "hi-red",
+		"object":         "hi-yellow",
+		"array":          "hi-magenta",
+		"other":          "white",
+		"multiline":      "white",
+		"stack-frame":    "hi-cyan",
+		"non-json":       "hi-black",
+		"stderr":         "red",
+		"slow-delta":     "hi-red bold",
+		"skewed-time":    "hi-yellow bold underline",
+		"highlight":      "black bg-hi-yellow",
+		"line-number":    "hi-yellow",
+		"source-palette": "cyan | yellow | green | magenta | blue | hi-cyan | hi-yellow | hi-green | hi-magenta | hi-blue",
+
+		"http-1xx":    "hi-black",
+		"http-2xx":    "hi-green",
+		"http-3xx":    "hi-cyan",
+		"http-4xx":    "hi-yellow",
+		"http-5xx":    "hi-red bold",
+		"http-get":    "hi-blue bold",
+		"http-post":   "hi-green bold",
+		"http-put":    "hi-yellow bold",
+		"http-patch":  "yellow bold",
+		"http-delete": "hi-red bold",
+		"http-method": "hi-magenta bold",
+		"http-path":   "white",
+		"http-addr":   "magenta",
+
+		"PANIC":   "red bold bg-hi-white",
+		"FATAL":   "hi-white bold bg-red",
+		"ERROR":   "hi-white bold bg-hi-red",
+		"WARN":    "hi-black bold bg-hi-yellow",
+		"INFO":    "hi-white bold bg-hi-blue",
+		"DEBUG":   "hi-white bold bg-hi-black",
+		"TRACE":   "hi-white bold bg-black",
+		"DEFAULT": "white bold bg-hi-black",
+	},
+	"light": {
+		"time":           "hi-black",
+		"message":        "black bold",
+		"field-key":      "hi-black",
+		"source":         "magenta",
+		"string":         "blue",
+		"number":         "cyan",
+		"bool":           "green",
+		"null":           "red",
+		"object":         "yellow",
+		"array":          "magenta",
+		"other":          "black",
+		"multiline":      "black",
+		"stack-frame":    "blue",
+		"non-json":       "hi-black",
+		"stderr":         "red",
+		"slow-delta":     "red bold",
+		"skewed-time":    "yellow bold underline",
+		"highlight":      "black bg-yellow",
+		"line-number":    "yellow",
+		"source-palette": "blue | magenta | green | cyan | red | yellow",
+
+		"http-1xx":    "hi-black",
+		"http-2xx":    "green",
+		"http-3xx":    "cyan",
+		"http-4xx":    "yellow",
+		"http-5xx":    "red bold",
+		"http-get":    "blue bold",
+		"http-post":   "green bold",
+		"http-put":    "yellow bold",
+		"http-patch":  "yellow bold",
+		"http-delete": "red bold",
+		"http-method": "magenta bold",
+		"http-path":   "black",
+		"http-addr":   "magenta",
+
+		"PANIC":   "hi-white bold bg-magenta",
+		"FATAL":   "hi-white bold bg-red",
+		"ERROR":   "hi-white bold bg-hi-red",
+		"WARN":    "black bold bg-yellow",
+		"INFO":    "hi-white bold bg-blue",
+		"DEBUG":   "black bold bg-white",
+		"TRACE":   "black bg-white",
+		"DEFAULT": "black bold bg-white",
+	},
+	// solarized assumes the terminal uses the solarized palette, where the
+	// bright colors are mapped to the base tones.
+	"solarized": {
+		"time":           "hi-green",
+		"message":        "hi-cyan bold",
+		"field-key":      "hi-green",
+		"source":         "hi-magenta",
+		"string":         "cyan",
+		"number":         "magenta",
+		"bool":           "green",
+		"null":           "hi-red",
+		"object":         "yellow",
+		"array":          "blue",
+		"other":          "hi-blue",
+		"multiline":      "hi-blue",
+		"stack-frame":    "yellow",
+		"non-json":       "hi-green",
+		"stderr":         "red",
+		"slow-delta":     "red bold",
+		"skewed-time":    "yellow bold underline",
+		"highlight":      "yellow bold reverse",
+		"line-number":    "yellow",
+		"source-palette": "cyan | yellow | green | magenta | blue | red",
+
+		"http-1xx":    "hi-green",
+		"http-2xx":    "green",
+		"http-3xx":    "cyan",
+		"http-4xx":    "yellow",
+		"http-5xx":    "red bold",
+		"http-get":    "blue bold",
+		"http-post":   "green bold",
+		"http-put":    "yellow bold",
+		"http-patch":  "hi-red bold",
+		"http-delete": "red bold",
+		"http-method": "magenta bold",
+		"http-path":   "hi-blue",
+		"http-addr":   "magenta",
+
+		"PANIC":   "hi-white bold bg-magenta",
+		"FATAL":   "hi-white bold bg-red",
+		"ERROR":   "red bold reverse",
+		"WARN":    "yellow bold reverse",
+		"INFO":    "blue bold reverse",
+		"DEBUG":   "cyan bold reverse",
+		"TRACE":   "hi-green bold reverse",
+		"DEFAULT": "hi-blue bold reverse",
+	},
+	"monochrome": {
+		"time":           "faint",
+		"message":        "bold",
+		"field-key":      "faint",
+		"source":         "underline",
+		"string":         "",
+		"number":         "",
+		"bool":           "",
+		"null":           "italic",
+		"object":         "faint",
+		"array":          "faint",
+		"other":          "",
+		"multiline":      "",
+		"stack-frame":    "underline",
+		"non-json":       "faint",
+		"stderr":         "bold",
+		"slow-delta":     "bold reverse",
+		"skewed-time":    "bold underline",
+		"highlight":      "reverse",
+		"line-number":    "bold",
+		"source-palette": "",
+
+		"http-1xx":    "faint",
+		"http-2xx":    "",
+		"http-3xx":    "",
+		"http-4xx":    "bold",
+		"http-5xx":    "bold reverse",
+		"http-get":    "bold",
+		"http-post":   "bold",
+		"http-put":    "bold",
+		"http-patch":  "bold",
+		"http-delete": "bold underline",
+		"http-method": "bold",
+		"http-path":   "",
+		"http-addr":   "",
+
+		"PANIC":   "bold reverse blink",
+		"FATAL":   "bold reverse",
+		"ERROR":   "bold reverse",
+		"WARN":    "bold underline",
+		"INFO":    "bold",
+		"DEBUG":   "faint",
+		"TRACE":   "faint",
+		"DEFAULT": "bold",
+	},
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	var names []string
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTheme creates the built-in theme with the given name ("dark" if empty),
+// with the element colors in overrides replacing the theme's.
+func NewTheme(name string, overrides map[string]string) (*Theme, error) {
+	if name == "" {
+		name = "dark"
+	}
+	specs, ok := builtinThemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	t := &Theme{Levels: map[string]*color.Color{}, HTTP: map[string]*color.Color{}}
+	apply := func(key, spec string) error {
+		if key == "source-palette" {
+			return t.setSourcePalette(spec)
+		}
+		c, err := parseColor(spec)
+		if err != nil {
+			return fmt.Errorf("colors.%s: %w", key, err)
+		}
+		if el := t.element(key); el != nil {
+			*el = c
+		} else if strings.HasPrefix(key, "http-") {
+			t.HTTP[strings.ToLower(key)] = c
+		} else {
+			t.Levels[strings.ToUpper(key)] = c
+		}
+		return nil
+	}
+	for key, spec := range specs {
+		if err := apply(key, spec); err != nil {
+			return nil, err
+		}
+	}
+	for key, spec := range overrides {
+		if err := apply(key, spec); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// setSourcePalette parses a list of color specs separated by "|".
+func (t *Theme) setSourcePalette(specs string) error {
+	t.SourcePalette = nil
+	for _, spec := range strings.Split(specs, "|") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		c, err := parseColor(spec)
+		if err != nil {
+			return fmt.Errorf("colors.source-palette: %w", err)
+		}
+		t.SourcePalette = append(t.SourcePalette, c)
+	}
+	return nil
+}
+
+// SourceColor returns the color of the i-th source label.
+func (t *Theme) SourceColor(i int) *color.Color {
+	if len(t.SourcePalette) == 0 {
+		return t.Source
+	}
+	return t.SourcePalette[i%len(t.SourcePalette)]
+}
+
+func (t *Theme) element(name string) **color.Color {
+	switch name {
+	case "time":
+		return &t.Time
+	case "message":
+		return &t.Message
+	case "field-key":
+		return &t.FieldKey
+	case "source":
+		return &t.Source
+	case "string":
+		return &t.String
+	case "number":
+		return &t.Number
+	case "bool":
+		return &t.Bool
+	case "null":
+		return &t.Null
+	case "object":
+		return &t.Object
+	case "array":
+		return &t.Array
+	case "other":
+		return &t.Other
+	case "multiline":
+		return &t.Multiline
+	case "stack-frame":
+		return &t.Frame
+	case "non-json":
+		return &t.NonJSON
+	case "stderr":
+		return &t.Stderr
+	case "slow-delta":
+		return &t.SlowDelta
+	case "skewed-time":
+		return &t.SkewedTime
+	case "highlight":
+		return &t.Highlight
+	case "line-number":
+		return &t.LineNumber
+	}
+	return nil
+}
+
+// SetColorEnabled forces the colors of the theme on or off, regardless of the
+// global color settings.
+func (t *Theme) SetColorEnabled(enabled bool) {
+	colors := []*color.Color{t.Time, t.Message, t.FieldKey, t.Source, t.String, t.Number, t.Bool, t.Null, t.Object, t.Array, t.Other, t.Multiline, t.Frame, t.NonJSON, t.Stderr, t.SlowDelta, t.SkewedTime, t.Highlight, t.LineNumber}
+	colors = append(colors, t.SourcePalette...)
+	for _, c := range t.Levels {
+		colors = append(colors, c)
+	}
+	for _, c := range t.HTTP {
+		colors = append(colors, c)
+	}
+	for _, c := range colors {
+		if c == nil {
+			continue
+		}
+		if enabled {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+}
+
+// colorEnabled resolves a --color mode (auto, always or never). In auto mode
+// colors are enabled when out is a terminal and NO_COLOR is not set.
+func colorEnabled(mode string, out io.Writer) (bool, error) {
+	switch mode {
+	case "", "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		f, ok := out.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()), nil
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid color mode %q (available: auto, always, never)", mode)
+}
+
+// Level returns the color of a normalized level.
+func (t *Theme) Level(level string) (*color.Color, bool) {
+	c, ok := t.Levels[level]
+	if !ok {
+		return t.Levels["DEFAULT"], false
+	}
+	return c, true
+}
+
+var colorAttributes = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"blink":     color.BlinkSlow,
+	"reverse":   color.ReverseVideo,
+
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+// parseColor parses a color spec made of space or comma separated
+// attributes. Colors can be prefixed with "hi-" for the high intensity
+// variant and with "bg-" to set the background, eg. "white bold bg-hi-red".
+// It returns nil for an empty spec.
+func parseColor(spec string) (*color.Color, error) {
+	attrs := strings.FieldsFunc(spec, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(attrs) == 0 {
+		// Painting with a color without attributes would still write an
+		// escape sequence, nil leaves the text as is.
+		return nil, nil
+	}
+	c := color.New()
+	for _, attr := range attrs {
+		name := strings.ToLower(attr)
+		offset := color.Attribute(0)
+		if strings.HasPrefix(name, "bg-") {
+			name = strings.TrimPrefix(name, "bg-")
+			offset += color.BgBlack - color.FgBlack
+		}
+		if strings.HasPrefix(name, "hi-") {
+			name = strings.TrimPrefix(name, "hi-")
+			offset += color.FgHiBlack - color.FgBlack
+		}
+		a, ok := colorAttributes[name]
+		if !ok || (offset != 0 && (a < color.FgBlack || a > color.FgWhite)) {
+			return nil, fmt.Errorf("invalid color %q", attr)
+		}
+		c.Add(a + offset)
+	}
+	return c, nil
+}
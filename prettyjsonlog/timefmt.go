@@ -0,0 +1,267 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/fatih/color"
+)
+
+// namedLayouts are the layouts of the time package that can be given by name.
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"DateTime":    time.DateTime,
+}
+
+// strptimeDirectives maps strptime directives to Go layout elements.
+var strptimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "999999999",
+	'p': "PM",
+	'z': "-0700",
+	'Z': "MST",
+	'T': "15:04:05",
+	'F': "2006-01-02",
+	'%': "%",
+}
+
+// goTimeLayout converts a time layout given in the config to a Go layout. It
+// can be the name of a layout of the time package (eg. RFC3339), a strptime
+// pattern (eg. "%Y-%m-%d %H:%M:%S") or a Go layout.
+func goTimeLayout(layout string) string {
+	if named, ok := namedLayouts[layout]; ok {
+		return named
+	}
+	if !strings.Contains(layout, "%") {
+		return layout
+	}
+	var sb strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] == '%' && i+1 < len(layout) {
+			if elem, ok := strptimeDirectives[layout[i+1]]; ok {
+				sb.WriteString(elem)
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(layout[i])
+	}
+	return sb.String()
+}
+
+// parseTimeLayouts parses s with the first matching time layout.
+func (p *PrettyJsonLog) parseTimeLayouts(s string) (time.Time, error) {
+	for _, layout := range p.timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q doesn't match the time layouts", s)
+}
+
+// epochUnits maps the units of numeric timestamps to their duration.
+var epochUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+// isEpoch reports whether a string timestamp is treated as an epoch: an
+// integer of 10 digits or more, or a decimal number. Shorter integers are
+// left to dateparse, they could be dates like 20210627.
+func isEpoch(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return false
+	}
+	return len(whole) >= 10 || (hasFrac && frac != "")
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseEpoch parses a numeric timestamp in the given unit. If unit is empty
+// or auto, it's guessed from the magnitude of the number, assuming the time
+// is between 1973 and 5138.
+func parseEpoch(s, unit string) (time.Time, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid epoch %q", s)
+	}
+	if unit == "" || unit == "auto" {
+		switch abs := math.Abs(f); {
+		case abs < 1e11:
+			unit = "s"
+		case abs < 1e14:
+			unit = "ms"
+		case abs < 1e17:
+			unit = "us"
+		default:
+			unit = "ns"
+		}
+	}
+	mult := epochUnits[unit]
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, n*int64(mult)), nil
+	}
+	// The fraction is parsed apart, a float64 loses the last digits of the
+	// times in seconds like the created field of Python (1717236002.789).
+	if whole, frac, ok := strings.Cut(s, "."); ok && len(frac) > 0 {
+		w, werr := strconv.ParseInt(whole, 10, 64)
+		fr, ferr := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+		if werr == nil && ferr == nil {
+			ns := fr * int64(mult) / 1e9
+			if strings.HasPrefix(whole, "-") {
+				ns = -ns
+			}
+			return time.Unix(0, w*int64(mult)+ns), nil
+		}
+	}
+	return time.Unix(0, int64(f*float64(mult))), nil
+}
+
+// renderTime renders the time of a line with its color, followed or replaced
+// by the time since the previous line if enabled. The times out of order or
+// in the future are rendered with the skewed-time color with --warn-skew.
+func (p *PrettyJsonLog) renderTime(t time.Time) string {
+	c := p.theme.Time
+	if p.skew != nil && p.skew.skewed(t) {
+		c = p.theme.SkewedTime
+	}
+	if p.config.OutputTimeFmt == "delta" {
+		return p.renderDelta(t, c)
+	}
+	s := p.paint(c, p.formatTime(t))
+	if p.config.TimeDelta {
+		s += " " + p.renderDelta(t, p.theme.Time)
+	}
+	return s
+}
+
+// renderDelta renders the time elapsed since the previous line with the
+// color c, highlighting it if it's above the delta threshold.
+func (p *PrettyJsonLog) renderDelta(t time.Time, c *color.Color) string {
+	p.timeMu.Lock()
+	var d time.Duration
+	if !p.prevTime.IsZero() {
+		d = t.Sub(p.prevTime)
+	}
+	p.prevTime = t
+	p.timeMu.Unlock()
+
+	s := fmt.Sprintf("Δ%-7s", formatDelta(d))
+	if p.config.DeltaThreshold > 0 && d > p.config.DeltaThreshold {
+		return p.paint(p.theme.SlowDelta, s)
+	}
+	return p.paint(c, s)
+}
+
+// formatDelta renders a duration like 152ms, 3.41s or 2m5s.
+func formatDelta(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case abs < time.Minute:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
+
+// formatTime renders the time of a line according to the time format: a
+// layout in the configured timezone, or an offset from the first line or the start of the process in
+// relative mode.
+func (p *PrettyJsonLog) formatTime(t time.Time) string {
+	if !p.relativeTime {
+		if p.location != nil {
+			t = t.In(p.location)
+		}
+		return t.Format(p.displayTimeFormat)
+	}
+	p.timeMu.Lock()
+	if p.timeOrigin.IsZero() {
+		p.timeOrigin = t
+	}
+	origin := p.timeOrigin
+	p.timeMu.Unlock()
+	return formatOffset(t.Sub(origin))
+}
+
+// formatOffset renders a duration like +00:03.412 (or +1:02:03.412 above an
+// hour).
+func formatOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	ms := d.Milliseconds()
+	h, m, s := ms/3600000, ms/60000%60, ms/1000%60
+	if h > 0 {
+		return fmt.Sprintf("%s%d:%02d:%02d.%03d", sign, h, m, s, ms%1000)
+	}
+	return fmt.Sprintf("%s%02d:%02d.%03d", sign, m, s, ms%1000)
+}
+
+// parseTimeBound parses the bound of a time range: a time, or a duration
+// before now like 15m or 2d.
+func parseTimeBound(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return dateparse.ParseLocal(s)
+}
+
+// inTimeRange reports whether t is within --since and --until.
+func (p *PrettyJsonLog) inTimeRange(t time.Time) bool {
+	return (p.since.IsZero() || !t.Before(p.since)) && (p.until.IsZero() || !t.After(p.until))
+}
@@ -0,0 +1,173 @@
+package prettyjsonlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// transformTimeout is the time waited for the output of a transformer
+// command for a line, after which the command is stopped and the lines are
+// shown as is.
+const transformTimeout = 5 * time.Second
+
+// transformer pipes the lines through a pool of commands, one per worker,
+// each one reading JSON lines on stdin and writing a line for each of them
+// on stdout, like the --transform-cmd `jq -c --unbuffered '.user |= .id'`.
+type transformer struct {
+	command string
+	pool    chan *transformProc
+	failed  sync.Once
+	// stuck is set once a command didn't answer in time.
+	stuck atomic.Bool
+}
+
+// transformProc is a running command of the pool, started when needed.
+type transformProc struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+}
+
+func newTransformer(command string, size int) *transformer {
+	t := &transformer{command: command, pool: make(chan *transformProc, max(size, 1))}
+	for i := 0; i < cap(t.pool); i++ {
+		t.pool <- &transformProc{}
+	}
+	return t
+}
+
+// transform returns the output of the command for a line, and false if the
+// command wrote an empty line or null to drop it. The line is returned as
+// is if the command fails.
+func (t *transformer) transform(line *logLine) (*logLine, bool) {
+	if t.stuck.Load() {
+		return line, true
+	}
+	data, err := json.Marshal(line.line)
+	if err != nil {
+		return line, true
+	}
+	out, err := t.call(data)
+	if err != nil {
+		t.failed.Do(func() { log.Printf("transform-cmd: %v", err) })
+		return line, true
+	}
+	if out == "" || out == "null" {
+		return nil, false
+	}
+	transformed, err := NewLogLine(out, line.p)
+	if err != nil {
+		t.failed.Do(func() { log.Printf("transform-cmd: invalid output %q: %v", out, err) })
+		return line, true
+	}
+	transformed.fallbackTime = line.fallbackTime
+	return transformed, true
+}
+
+// call writes a line to a command of the pool and returns the line it
+// writes back.
+func (t *transformer) call(data []byte) (string, error) {
+	if t.stuck.Load() {
+		return "", fmt.Errorf("%q is stopped", t.command)
+	}
+	proc := <-t.pool
+	defer func() { t.pool <- proc }()
+	return t.run(proc, data)
+}
+
+// run writes a line to the command, started if needed, and reads its output.
+// The command is stopped if it fails, to be started again for the next
+// line, or if it doesn't answer in time.
+func (t *transformer) run(proc *transformProc, data []byte) (string, error) {
+	if proc.cmd == nil {
+		if err := t.start(proc); err != nil {
+			return "", err
+		}
+	}
+	if _, err := proc.stdin.Write(append(data, '\n')); err != nil {
+		proc.stop()
+		return "", err
+	}
+	select {
+	case out, ok := <-proc.lines:
+		if !ok {
+			proc.stop()
+			return "", fmt.Errorf("%q exited", t.command)
+		}
+		return out, nil
+	case <-time.After(transformTimeout):
+		t.stuck.Store(true)
+		proc.stop()
+		return "", fmt.Errorf("%q didn't write a line within %s", t.command, transformTimeout)
+	}
+}
+
+func (t *transformer) start(proc *transformProc) error {
+	cmd := exec.Command("sh", "-c", t.command)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		r := bufio.NewReader(stdout)
+		for {
+			line, _, err := readLine(r, nil, 0)
+			if err != nil {
+				return
+			}
+			lines <- string(line)
+		}
+	}()
+	*proc = transformProc{cmd: cmd, stdin: stdin, lines: lines}
+	return nil
+}
+
+// stop ends the command, to be started again for the next line.
+func (proc *transformProc) stop() {
+	proc.stdin.Close()
+	proc.cmd.Process.Kill()
+	go func(cmd *exec.Cmd, lines chan string) {
+		for range lines {
+		}
+		cmd.Wait()
+	}(proc.cmd, proc.lines)
+	*proc = transformProc{}
+}
+
+// close ends the commands once they've read all the lines.
+func (t *transformer) close() {
+	for i := 0; i < cap(t.pool); i++ {
+		proc := <-t.pool
+		if proc.cmd != nil {
+			proc.stdin.Close()
+			for range proc.lines {
+			}
+			proc.cmd.Wait()
+		}
+	}
+}
+
+// closeTransform ends the --transform-cmd commands, if any.
+func (p *PrettyJsonLog) closeTransform() {
+	if p.transformer != nil {
+		p.transformer.close()
+	}
+}
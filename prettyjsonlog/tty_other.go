@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package prettyjsonlog
+
+import "errors"
+
+func setCbreak(fd int) (func(), error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,253 @@
+package prettyjsonlog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxTUIRows is the number of rendered rows kept in the scrollback buffer.
+const maxTUIRows = 100000
+
+type tuiRow struct {
+	text  string
+	plain string
+	level string
+}
+
+type tuiRowsMsg []tuiRow
+
+type tuiEOFMsg struct{}
+
+// tuiModel is an interactive log viewer with scrollback, search and follow
+// mode, fed by the log entries read by Run.
+type tuiModel struct {
+	p *PrettyJsonLog
+
+	rows   []tuiRow
+	top    int
+	width  int
+	height int
+	follow bool
+	eof    bool
+
+	searching bool
+	query     string
+	input     string
+	selected  int
+	status    string
+}
+
+// runTUI shows the entries read from ch in the interactive viewer until the
+// user quits. Keys are read from the terminal since stdin is the log stream.
+func (p *PrettyJsonLog) runTUI(ch <-chan logEntry, doneCh <-chan struct{}) error {
+	m := &tuiModel{p: p, follow: true, selected: -1}
+	prog := tea.NewProgram(m, tea.WithAltScreen(), tea.WithInputTTY())
+
+	go func() {
+		for entry := range ch {
+			out, level, ok := p.formatEntry(entry)
+			if !ok {
+				continue
+			}
+			var rows tuiRowsMsg
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+				rows = append(rows, tuiRow{text: line, plain: ansi.Strip(line), level: level})
+			}
+			prog.Send(rows)
+		}
+	}()
+	go func() {
+		<-doneCh
+		prog.Send(tuiEOFMsg{})
+	}()
+
+	_, err := prog.Run()
+	return err
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clamp()
+	case tuiRowsMsg:
+		m.rows = append(m.rows, msg...)
+		if over := len(m.rows) - maxTUIRows; over > 0 {
+			m.rows = m.rows[over:]
+			m.top -= over
+			m.selected -= over
+		}
+		m.clamp()
+	case tuiEOFMsg:
+		m.eof = true
+	case tea.KeyMsg:
+		if m.searching {
+			return m, m.updateSearch(msg)
+		}
+		return m, m.updateKeys(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		m.query = m.input
+		m.findNext(m.top-1, 1, func(r tuiRow) bool { return m.query != "" && strings.Contains(r.plain, m.query) })
+	case tea.KeyBackspace:
+		if m.input != "" {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+	return nil
+}
+
+func (m *tuiModel) updateKeys(msg tea.KeyMsg) tea.Cmd {
+	page := m.pageHeight()
+	matchesQuery := func(r tuiRow) bool { return m.query != "" && strings.Contains(r.plain, m.query) }
+	isError := func(r tuiRow) bool {
+		severity, ok := m.p.levelSeverity[r.level]
+		return ok && severity >= m.p.levelSeverity["ERROR"]
+	}
+	m.status = ""
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.scroll(-1)
+	case "down", "j":
+		m.scroll(1)
+	case "pgup", "b":
+		m.scroll(-page)
+	case "pgdown", " ":
+		m.scroll(page)
+	case "home", "g":
+		m.follow = false
+		m.top = 0
+	case "end", "G":
+		m.follow = true
+		m.clamp()
+	case "F":
+		m.follow = !m.follow
+		m.clamp()
+	case "/":
+		m.searching = true
+		m.input = ""
+	case "n":
+		m.findNext(m.selected, 1, matchesQuery)
+	case "N":
+		m.findNext(m.selected, -1, matchesQuery)
+	case "e":
+		m.findNext(m.selected, 1, isError)
+	case "E":
+		m.findNext(m.selected, -1, isError)
+	}
+	return nil
+}
+
+// findNext selects the first row after (or before, if dir is -1) the row at
+// from matching the predicate and scrolls to it.
+func (m *tuiModel) findNext(from, dir int, match func(tuiRow) bool) {
+	for i := from + dir; i >= 0 && i < len(m.rows); i += dir {
+		if match(m.rows[i]) {
+			m.selected = i
+			m.follow = false
+			if i < m.top || i >= m.top+m.pageHeight() {
+				m.top = i - m.pageHeight()/2
+			}
+			m.clamp()
+			return
+		}
+	}
+	m.status = "no more matches"
+}
+
+func (m *tuiModel) scroll(n int) {
+	m.top += n
+	m.follow = false
+	m.clamp()
+	if m.top == m.maxTop() {
+		m.follow = true
+	}
+}
+
+func (m *tuiModel) pageHeight() int {
+	if m.height <= 1 {
+		return 1
+	}
+	return m.height - 1
+}
+
+func (m *tuiModel) maxTop() int {
+	if n := len(m.rows) - m.pageHeight(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (m *tuiModel) clamp() {
+	if m.follow || m.top > m.maxTop() {
+		m.top = m.maxTop()
+	}
+	if m.top < 0 {
+		m.top = 0
+	}
+}
+
+func (m *tuiModel) View() string {
+	var sb strings.Builder
+	page := m.pageHeight()
+	for i := m.top; i < m.top+page; i++ {
+		if i < len(m.rows) {
+			row := m.rows[i].text
+			if i == m.selected {
+				row = "\x1b[7m" + m.rows[i].plain + "\x1b[0m"
+			}
+			if m.width > 0 {
+				row = ansi.Truncate(row, m.width, "…")
+			}
+			sb.WriteString(row)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(m.statusLine())
+	return sb.String()
+}
+
+func (m *tuiModel) statusLine() string {
+	if m.searching {
+		return "/" + m.input
+	}
+	mode := "PAUSED"
+	if m.follow {
+		mode = "FOLLOW"
+	}
+	if m.eof {
+		mode += " (EOF)"
+	}
+	status := fmt.Sprintf(" %s | %d/%d | q quit, / search, n/N next/prev match, e/E next/prev error, F follow", mode, min(m.top+m.pageHeight(), len(m.rows)), len(m.rows))
+	if m.query != "" {
+		status += " | /" + m.query
+	}
+	if m.status != "" {
+		status += " | " + m.status
+	}
+	if m.width > 0 {
+		status = ansi.Truncate(status, m.width, "…")
+	}
+	return "\x1b[7m" + status + "\x1b[0m"
+}
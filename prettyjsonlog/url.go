@@ -0,0 +1,74 @@
+package prettyjsonlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// isHTTPURL tells if an input is an http:// or https:// URL.
+func isHTTPURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// urlReader reads the body of the response to a GET request, decompressed if
+// needed, as it's received.
+type urlReader struct {
+	io.Reader
+	ctx    context.Context
+	cancel context.CancelFunc
+	body   io.Closer
+}
+
+// openURL requests a URL with the headers of the config, the user and
+// password of the URL are sent with basic authentication.
+func (p *PrettyJsonLog) openURL(rawURL string) (*urlReader, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for _, header := range p.config.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			cancel()
+			return nil, fmt.Errorf("invalid header %q (eg. 'Authorization: Bearer token')", header)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s: unexpected status %s", req.URL.Redacted(), resp.Status)
+	}
+	r, err := decompress(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s: %w", req.URL.Redacted(), err)
+	}
+	return &urlReader{Reader: r, ctx: ctx, cancel: cancel, body: resp.Body}, nil
+}
+
+// Read reports the end of the body instead of an error once closed.
+func (u *urlReader) Read(b []byte) (int, error) {
+	n, err := u.Reader.Read(b)
+	if err != nil && u.ctx.Err() != nil {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops the request, eg. of a response streamed until interrupted.
+func (u *urlReader) Close() error {
+	u.cancel()
+	return u.body.Close()
+}
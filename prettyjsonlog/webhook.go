@@ -0,0 +1,180 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	// webhookQueueSize is the number of matching lines waiting to be sent,
+	// the lines matching when it's full are skipped.
+	webhookQueueSize = 100
+	// webhookTimeout bounds the time spent sending a line.
+	webhookTimeout = 10 * time.Second
+)
+
+// webhook posts the lines shown matching the --webhook-on-match filter to
+// the --webhook URL, at most rate lines per period. The lines over the rate
+// are skipped, and counted in the next payload.
+type webhook struct {
+	p        *PrettyJsonLog
+	url      string
+	filter   *lineFilter
+	template *template.Template
+	rate     int
+	period   time.Duration
+	client   *http.Client
+
+	// mu is held while queuing a line, and by close to stop the queue.
+	mu        sync.Mutex
+	closed    bool
+	windowEnd time.Time
+	inWindow  int
+	skipped   int
+
+	lines  chan map[string]interface{}
+	done   chan struct{}
+	failed sync.Once
+}
+
+func (p *PrettyJsonLog) newWebhook() (*webhook, error) {
+	c := p.config
+	f, err := newLineFilter(c.WebhookOnMatch)
+	if err != nil {
+		return nil, err
+	}
+	w := &webhook{p: p, url: c.Webhook, filter: f, client: &http.Client{Timeout: webhookTimeout}}
+	rate := c.WebhookRate
+	if rate == "" {
+		rate = "10/m"
+	}
+	if w.rate, w.period, err = parseRate(rate); err != nil {
+		return nil, fmt.Errorf("invalid webhook rate %q (eg. 10/m)", rate)
+	}
+	text := c.WebhookTemplate
+	if text == "" {
+		text = defaultWebhookTemplate(c.Webhook)
+	}
+	if w.template, err = p.newFormatTemplate(text); err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	w.lines = make(chan map[string]interface{}, webhookQueueSize)
+	w.done = make(chan struct{})
+	go w.run()
+	return w, nil
+}
+
+// defaultWebhookTemplate returns the payload of the Slack and Discord
+// webhooks, and the line as is for the others.
+func defaultWebhookTemplate(url string) string {
+	switch {
+	case strings.Contains(url, "hooks.slack.com/"):
+		return `{"text": {{json .text}}}`
+	case strings.Contains(url, "discord.com/api/webhooks/"), strings.Contains(url, "discordapp.com/api/webhooks/"):
+		return `{"content": {{json .text}}}`
+	}
+	return `{{.json}}`
+}
+
+// check queues the line to be sent if it matches and the rate allows it.
+func (w *webhook) check(line *logLine, source string) {
+	if !w.filter.match(line.decoded()) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if now := time.Now(); now.After(w.windowEnd) {
+		w.windowEnd, w.inWindow = now.Add(w.period), 0
+	}
+	if w.inWindow >= w.rate {
+		w.skipped++
+		return
+	}
+	data, err := json.Marshal(line.line)
+	if err != nil {
+		return
+	}
+	level, _ := line.level()
+	msg, _ := line.message()
+	payload := map[string]interface{}{
+		"level":   level,
+		"msg":     msg,
+		"source":  source,
+		"fields":  line.decodedNumbers(),
+		"json":    string(data),
+		"skipped": w.skipped,
+	}
+	if t, ok := line.parsedTime(); ok {
+		payload["time"] = t.Format(time.RFC3339Nano)
+	}
+	text := level + " " + msg
+	if w.skipped > 0 {
+		text += fmt.Sprintf(" (%d more matching lines skipped)", w.skipped)
+	}
+	payload["text"] = text + "\n```" + string(data) + "```"
+	select {
+	case w.lines <- payload:
+		w.inWindow++
+		w.skipped = 0
+	default:
+		w.skipped++
+	}
+}
+
+// run sends the queued lines one at a time.
+func (w *webhook) run() {
+	defer close(w.done)
+	for payload := range w.lines {
+		if err := w.send(payload); err != nil {
+			w.failed.Do(func() { log.Printf("webhook: %v", err) })
+		}
+	}
+}
+
+func (w *webhook) send(payload map[string]interface{}) error {
+	var body bytes.Buffer
+	if err := w.template.Execute(&body, payload); err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// close waits for the lines queued to be sent, and logs the number of
+// lines skipped after the last one.
+func (w *webhook) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.lines)
+		if w.skipped > 0 {
+			log.Printf("webhook: %d matching lines skipped by the rate limit", w.skipped)
+		}
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// closeWebhook waits for the lines still being sent to the webhook, if any.
+func (p *PrettyJsonLog) closeWebhook() {
+	if p.webhook != nil {
+		p.webhook.close()
+	}
+}
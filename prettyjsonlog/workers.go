@@ -0,0 +1,77 @@
+package prettyjsonlog
+
+import (
+	"errors"
+)
+
+// formattedEntry is a rendered log entry, ok is false if it's filtered out.
+type formattedEntry struct {
+	out string
+	ok  bool
+}
+
+type formatJob struct {
+	entry  logEntry
+	result chan<- formattedEntry
+}
+
+// checkWorkers rejects the options depending on the order the lines are
+// formatted in, which isn't kept with several workers.
+func (p *PrettyJsonLog) checkWorkers() error {
+	c := p.config
+	if c.Workers <= 1 {
+		return nil
+	}
+	if c.Dedupe || c.GroupBy != "" || c.Sample != "" || c.RateLimit != "" || c.TimeDelta || c.Gap > 0 || c.WarnSkew > 0 ||
+		c.OutputTimeFmt == "delta" || c.OutputTimeFmt == "relative" || c.Sink != "" {
+		return errors.New("--workers can't be used with --dedupe, --group-by, --sample, --rate-limit, --time-delta, --gap, --warn-skew, --time-format delta or relative, or --sink, which depend on the order of the lines")
+	}
+	return nil
+}
+
+// formatEntries renders the entries read from ch and sends them to the
+// returned channel in the same order, closing it once ch is closed. With
+// more than one worker the entries are rendered concurrently: each one is
+// given a channel receiving its result, queued in order, and the results
+// are sent in the order of the queue as they are ready.
+func (p *PrettyJsonLog) formatEntries(ch <-chan logEntry) <-chan formattedEntry {
+	workers := p.config.Workers
+	results := make(chan formattedEntry, max(workers, 1)*4)
+	if workers <= 1 {
+		go func() {
+			defer close(results)
+			for entry := range ch {
+				out, _, ok := p.formatEntry(entry)
+				results <- formattedEntry{out: out, ok: ok}
+			}
+		}()
+		return results
+	}
+
+	jobs := make(chan formatJob, workers)
+	queue := make(chan chan formattedEntry, workers*4)
+	go func() {
+		defer close(queue)
+		defer close(jobs)
+		for entry := range ch {
+			result := make(chan formattedEntry, 1)
+			queue <- result
+			jobs <- formatJob{entry: entry, result: result}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				out, _, ok := p.formatEntry(job.entry)
+				job.result <- formattedEntry{out: out, ok: ok}
+			}
+		}()
+	}
+	go func() {
+		defer close(results)
+		for result := range queue {
+			results <- <-result
+		}
+	}()
+	return results
+}
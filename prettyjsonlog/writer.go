@@ -0,0 +1,88 @@
+package prettyjsonlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Formatter is an io.Writer that pretty prints the log lines written to it to
+// another writer. It can be used as the output of a JSON logger, eg.
+//
+//	logger := zerolog.New(prettyjsonlog.NewWriter(os.Stdout))
+type Formatter struct {
+	p *PrettyJsonLog
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter creates a Formatter writing to out with the default config.
+func NewWriter(out io.Writer) *Formatter {
+	f, err := NewFormatter(out, PrettyJsonLogConfig{})
+	if err != nil {
+		// the default config is always valid
+		panic(err)
+	}
+	return f
+}
+
+// NewFormatter creates a Formatter writing to out with the given config.
+func NewFormatter(out io.Writer, config PrettyJsonLogConfig) (*Formatter, error) {
+	p, err := newPrettyJsonLog(config, out)
+	if err != nil {
+		return nil, err
+	}
+	return &Formatter{p: p}, nil
+}
+
+// Write formats every complete line in b. Incomplete lines are buffered until
+// the rest of the line is written or Flush is called.
+func (f *Formatter) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buf = append(f.buf, b...)
+	for {
+		i := bytes.IndexByte(f.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(f.buf[:i])
+		f.buf = f.buf[i+1:]
+		if err := f.writeLine(line); err != nil {
+			return len(b), err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush formats the buffered incomplete line, if any, and the repeat count of
+// the last line with the dedupe option.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	line := string(f.buf)
+	f.buf = nil
+	if err := f.writeLine(line); err != nil {
+		return err
+	}
+	if f.p.dedupe != nil {
+		_, err := io.WriteString(f.p.out, f.p.dedupe.flush())
+		return err
+	}
+	return nil
+}
+
+func (f *Formatter) writeLine(line string) error {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	out, _, ok := f.p.formatEntry(logEntry{text: line})
+	if !ok {
+		return nil
+	}
+	_, err := io.WriteString(f.p.out, out)
+	return err
+}
@@ -0,0 +1,74 @@
+// Package s3 reads the objects of Amazon S3 buckets as inputs of the
+// formatter, with s3://bucket/key URLs and the default AWS credentials.
+// Importing it registers the s3 scheme.
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+)
+
+func init() {
+	prettyjsonlog.RegisterObjectStore("s3", list)
+}
+
+// list returns the object with the key of the URL, or the objects whose
+// key starts with it in lexicographic order, skipping the folder markers. A custom endpoint (eg.
+// $AWS_ENDPOINT_URL for MinIO) is addressed with path-style URLs.
+func list(ctx context.Context, u *url.URL) ([]prettyjsonlog.Object, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = cfg.BaseEndpoint != nil
+		// The objects uploaded without a checksum are common.
+		o.DisableLogOutputChecksumValidationSkipped = true
+	})
+	bucket, prefix := u.Host, strings.TrimPrefix(u.Path, "/")
+	object := func(key string) prettyjsonlog.Object {
+		return prettyjsonlog.Object{
+			Name: "s3://" + bucket + "/" + key,
+			Open: func(ctx context.Context) (io.ReadCloser, error) {
+				out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+				if err != nil {
+					return nil, err
+				}
+				return out.Body, nil
+			},
+		}
+	}
+	var objects []prettyjsonlog.Object
+	pages := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			if prefix != "" && objects == nil {
+				// Without the permission to list the bucket the key can
+				// still be read.
+				return []prettyjsonlog.Object{object(prefix)}, nil
+			}
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if key == prefix && !strings.HasSuffix(key, "/") {
+				return []prettyjsonlog.Object{object(key)}, nil
+			}
+			if !strings.HasSuffix(key, "/") {
+				objects = append(objects, object(key))
+			}
+		}
+	}
+	return objects, nil
+}
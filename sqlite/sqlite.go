@@ -0,0 +1,201 @@
+// Package sqlite stores the parsed lines in a SQLite database with
+// --sink sqlite:path, and shows the lines stored again. Importing it
+// registers the sqlite sink.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blesswinsamuel/pretty-json-log/prettyjsonlog"
+	_ "modernc.org/sqlite"
+)
+
+// schema is the table of the lines. time is in UTC with a fixed width, to be
+// compared as text (eg. time >= '2024-06-01T10:00').
+const schema = `
+CREATE TABLE IF NOT EXISTS logs (
+	id      INTEGER PRIMARY KEY,
+	time    TEXT,
+	level   TEXT,
+	message TEXT,
+	source  TEXT,
+	line    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS logs_time ON logs (time);
+`
+
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+const (
+	// batchSize and batchDelay bound the lines stored in a transaction
+	// before it's committed.
+	batchSize  = 1000
+	batchDelay = time.Second
+)
+
+func init() {
+	prettyjsonlog.RegisterSink("sqlite", Open)
+}
+
+type sink struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	tx      *sql.Tx
+	insert  *sql.Stmt
+	pending int
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Open returns a sink storing the lines in the logs table of the database
+// at path, created if needed.
+func Open(path string) (prettyjsonlog.Sink, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s := &sink{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+	go s.commitLoop()
+	return s, nil
+}
+
+func open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func (s *sink) Store(entry prettyjsonlog.SinkEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx == nil {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		insert, err := tx.Prepare("INSERT INTO logs (time, level, message, source, line) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		s.tx, s.insert = tx, insert
+	}
+	var t any
+	if !entry.Time.IsZero() {
+		t = entry.Time.UTC().Format(timeFormat)
+	}
+	if _, err := s.insert.Exec(t, entry.Level, entry.Message, entry.Source, entry.Line); err != nil {
+		return err
+	}
+	s.pending++
+	if s.pending >= batchSize {
+		return s.commit()
+	}
+	return nil
+}
+
+// commitLoop commits the lines stored every batchDelay, for the lines of a
+// live stream to be queried without waiting for more.
+func (s *sink) commitLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(batchDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.commit()
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *sink) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Commit()
+	s.tx, s.insert, s.pending = nil, nil, 0
+	return err
+}
+
+func (s *sink) Close() error {
+	close(s.stop)
+	<-s.done
+	s.mu.Lock()
+	err := s.commit()
+	s.mu.Unlock()
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Query pretty prints the lines stored in the database at path matching
+// where, a SQL condition on the columns of the logs table (time, level,
+// message, source and line, eg. level = 'ERROR' or
+// json_extract(line, '$.status') >= 500), all of them if empty.
+func Query(p *prettyjsonlog.PrettyJsonLog, path, where string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	query := "SELECT line FROM logs"
+	if strings.TrimSpace(where) != "" {
+		query += " WHERE " + where
+	}
+	rows, err := db.Query(query + " ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	r, w := io.Pipe()
+	sources := make(chan prettyjsonlog.Source, 1)
+	sources <- prettyjsonlog.Source{Reader: r}
+	close(sources)
+	stopped := make(chan struct{})
+	var once sync.Once
+	go func() {
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+			select {
+			case <-stopped:
+				w.Close()
+				return
+			default:
+			}
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return
+			}
+		}
+		w.CloseWithError(rows.Err())
+	}()
+	return p.RunSources(sources, func() {
+		once.Do(func() { close(stopped) })
+	})
+}